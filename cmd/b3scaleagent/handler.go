@@ -70,6 +70,9 @@ func (h *EventHandler) Dispatch(ctx context.Context, e bbb.Event) error {
 	case *bbb.UserLeftMeetingEvent:
 		return h.onUserLeftMeeting(ctx, e.(*bbb.UserLeftMeetingEvent))
 
+	case *bbb.BreakoutRoomStartedEvent:
+		return h.onBreakoutRoomStarted(ctx, e.(*bbb.BreakoutRoomStartedEvent))
+
 	default:
 		log.Error().
 			Str("type", fmt.Sprintf("%T", e)).
@@ -180,3 +183,26 @@ func (h *EventHandler) onUserLeftMeeting(
 
 	return nil
 }
+
+// handle event: BreakoutRoomStarted
+func (h *EventHandler) onBreakoutRoomStarted(
+	ctx context.Context,
+	e *bbb.BreakoutRoomStartedEvent,
+) error {
+	log.Info().
+		Str("parentInternalMeetingID", e.ParentInternalMeetingID).
+		Str("internalMeetingID", e.Breakout.BreakoutID).
+		Str("name", e.Breakout.Name).
+		Msg("breakout room started")
+
+	_, err := h.api.AgentRPC(
+		ctx, api.RPCMeetingAddBreakout(&api.MeetingAddBreakoutRequest{
+			ParentInternalMeetingID: e.ParentInternalMeetingID,
+			Breakout:                e.Breakout,
+		}))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -172,8 +172,11 @@ func (c *Cli) showBackends(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	// Check if backend exists
-	backends, err := client.BackendsList(ctx.Context)
+	query := url.Values{}
+	if ctx.Bool("idle") {
+		query.Set("idle", "true")
+	}
+	backends, err := client.BackendsList(ctx.Context, query)
 	if err != nil {
 		return err
 	}
@@ -192,7 +195,12 @@ func (c *Cli) showBackends(ctx *cli.Context) error {
 			b.AttendeesCount,
 			ratio)
 		fmt.Printf("  LoadFactor:\t %v\n", b.LoadFactor)
-		fmt.Printf("  Latency:\t %v\n", b.Latency)
+		fmt.Printf("  Latency:\t %v (median %v)\n",
+			b.Latency, b.MedianLatency())
+		fmt.Printf("  Circuit:\t %s\n", b.Circuit.State)
+		if b.ReadyAt != nil {
+			fmt.Printf("  ReadyAt:\t %v\n", b.ReadyAt)
+		}
 		if b.NodeState == "error" && b.LastError != nil {
 			fmt.Println("  LastError:", *b.LastError)
 		}
@@ -13,6 +13,7 @@ import (
 	"github.com/b3scale/b3scale/pkg/config"
 	"github.com/b3scale/b3scale/pkg/http/api"
 	"github.com/b3scale/b3scale/pkg/http/api/client"
+	"github.com/b3scale/b3scale/pkg/store"
 )
 
 // RetNoChange indicates the return code, that no
@@ -46,8 +47,14 @@ func NewCli() *Cli {
 				Usage:   "show the cluster state",
 				Subcommands: []*cli.Command{
 					{
-						Name:   "backends",
-						Usage:  "show the cluster backends",
+						Name:  "backends",
+						Usage: "show the cluster backends",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "idle",
+								Usage: "only show backends without meetings",
+							},
+						},
 						Action: c.showBackends,
 					},
 					{
@@ -65,6 +72,38 @@ func NewCli() *Cli {
 						Usage:  "show frontend settings",
 						Action: c.showFrontend,
 					},
+					{
+						Name:      "maintenance",
+						Usage:     "show maintenance windows, optionally of a backend",
+						ArgsUsage: "[host]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "state",
+								Usage: "only show windows in this state: scheduled, active or done",
+							},
+						},
+						Action: c.showMaintenance,
+					},
+					{
+						Name:  "commands",
+						Usage: "show the command queue",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "state",
+								Usage: "only show commands in this state, e.g. failed",
+							},
+							&cli.StringFlag{
+								Name:  "action",
+								Usage: "only show commands with this action",
+							},
+						},
+						Action: c.showCommands,
+					},
+					{
+						Name:   "instances",
+						Usage:  "show the b3scaled instances serving the cluster",
+						Action: c.showInstances,
+					},
 				},
 			},
 			{
@@ -111,6 +150,33 @@ func NewCli() *Cli {
 						},
 						Action: c.setFrontend,
 					},
+					{
+						Name:      "maintenance",
+						Usage:     "schedule a maintenance window for a backend",
+						ArgsUsage: "<host>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "start",
+								Usage:    "disable the backend at this time (YYYY-MM-DD HH:MM)",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "end",
+								Usage:    "enable the backend again at this time (YYYY-MM-DD HH:MM)",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "mode",
+								Usage: "drain: stop routing new meetings, force: also end the remaining meetings at the deadline",
+								Value: store.MaintenanceDrain,
+							},
+							&cli.StringFlag{
+								Name:  "deadline",
+								Usage: "in force mode, end the remaining meetings at this time (default: start)",
+							},
+						},
+						Action: c.setMaintenance,
+					},
 				},
 			},
 			{
@@ -138,6 +204,12 @@ func NewCli() *Cli {
 						Usage:  "delete frontend",
 						Action: c.deleteFrontend,
 					},
+					{
+						Name:      "maintenance",
+						Usage:     "delete a maintenance window, an active window is ended",
+						ArgsUsage: "<id>",
+						Action:    c.deleteMaintenance,
+					},
 				},
 			},
 			{
@@ -174,17 +246,60 @@ func NewCli() *Cli {
 					},
 				},
 			},
+			{
+				Name:  "requeue",
+				Usage: "run failed commands again",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry",
+						Usage: "perform a dry run",
+					},
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "commands",
+						Aliases:   []string{"command"},
+						Usage:     "requeue failed commands by ID",
+						ArgsUsage: "[id ...]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "failed",
+								Usage: "requeue all failed commands",
+							},
+						},
+						Action: c.requeueCommands,
+					},
+				},
+			},
 			{
 				Name:  "end",
 				Usage: "force ending things on a backend",
 				Subcommands: []*cli.Command{
 					{
-						Name:   "meetings",
-						Usage:  "end all meetings on a given <host>",
+						Name:  "meetings",
+						Usage: "end all meetings on a given <host>, or of a frontend",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "frontend",
+								Usage: "end all meetings of the frontend with this key on all backends",
+							},
+						},
 						Action: c.endAllMeetings,
 					},
 				},
 			},
+			{
+				Name:  "explain",
+				Usage: "explain decisions of the cluster",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "routing",
+						Usage:     "explain the backend selection for a create request of a frontend",
+						ArgsUsage: "<frontend key> [param=value ...]",
+						Action:    c.explainRouting,
+					},
+				},
+			},
 			{
 				Name:   "version",
 				Action: c.showVersion,
@@ -348,6 +463,10 @@ func (c *Cli) showStatus(ctx *cli.Context) error {
 	fmt.Println("")
 	fmt.Println("server version:", status.Version, "\tbuild:", status.Build)
 	fmt.Println("   api version:", status.API)
+	if status.Leader != nil {
+		fmt.Println("        leader:", status.Leader.Holder,
+			"\tsince:", status.Leader.AcquiredAt.Format(time.RFC3339))
+	}
 	fmt.Println("")
 
 	// Show migrations status
@@ -438,6 +557,10 @@ func (c *Cli) authorizeAPI(ctx *cli.Context) error {
 
 // end all meetings on a backend
 func (c *Cli) endAllMeetings(ctx *cli.Context) error {
+	if ctx.IsSet("frontend") {
+		return c.endFrontendMeetings(ctx)
+	}
+
 	// Args should be host
 	if ctx.NArg() < 1 {
 		return fmt.Errorf("require: <host>")
@@ -459,24 +582,64 @@ func (c *Cli) endAllMeetings(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	return awaitCommand(ctx, client, cmd)
+}
+
+// end all meetings of a frontend on all backends
+func (c *Cli) endFrontendMeetings(ctx *cli.Context) error {
+	key := ctx.String("frontend")
+	if key == "" {
+		return fmt.Errorf("require: --frontend <key>")
+	}
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	frontend, err := getFrontendByKey(ctx.Context, client, key)
+	if err != nil {
+		return err
+	}
+	if frontend == nil {
+		return fmt.Errorf("no such frontend")
+	}
+
+	cmd, err := client.FrontendMeetingsEnd(ctx.Context, frontend.ID)
+	if err != nil {
+		return err
+	}
+	return awaitCommand(ctx, client, cmd)
+}
+
+// awaitCommand polls the state of a command until
+// it succeeded or failed.
+func awaitCommand(
+	ctx *cli.Context,
+	client api.Client,
+	cmd *store.Command,
+) error {
 	fmt.Println("Dispatch:", cmd.Action, cmd.Params)
 
 	// Poll state changes
 	state := cmd.State
+	attempts := cmd.Attempts
 	for {
 		update, err := client.CommandRetrieve(ctx.Context, cmd.ID)
 		if err != nil {
 			return err
 		}
-		if update.State != state {
-			fmt.Println("State:", update.State)
+		if update.State != state || update.Attempts != attempts {
+			fmt.Println("State:", update.State,
+				"Attempt:", update.Attempts, "/", update.MaxAttempts)
 		}
-		if update.State == "success" || update.State == "error" {
+		if update.State == store.CommandSuccess ||
+			update.State == store.CommandError ||
+			update.State == store.CommandFailed {
 			fmt.Println("Result:", update.Result)
 			break
 		}
 
 		state = update.State
+		attempts = update.Attempts
 		time.Sleep(500 * time.Millisecond)
 	}
 	return nil
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// showCommands displays the command queue
+func (c *Cli) showCommands(ctx *cli.Context) error {
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	if ctx.IsSet("state") {
+		query.Set("state", ctx.String("state"))
+	}
+	if ctx.IsSet("action") {
+		query.Set("action", ctx.String("action"))
+	}
+	cmds, err := client.CommandsList(ctx.Context, query)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range cmds {
+		params, _ := json.Marshal(cmd.Params)
+		result, _ := json.Marshal(cmd.Result)
+		fmt.Printf("%s\n  Action:\t %s\t", cmd.ID, cmd.Action)
+		fmt.Printf("  Priority:\t %d\n", cmd.Priority)
+		fmt.Printf("  Params:\t %s\n", string(params))
+		fmt.Printf("  State:\t %s\t", cmd.State)
+		fmt.Printf("  Attempts:\t %d/%d\n", cmd.Attempts, cmd.MaxAttempts)
+		if cmd.NotBefore != nil && cmd.State == store.CommandRequested {
+			fmt.Printf("  NextAttempt:\t %v\n", cmd.NotBefore.Local())
+		}
+		fmt.Printf("  Result:\t %s\n", string(result))
+		fmt.Printf("  CreatedAt:\t %v\n", cmd.CreatedAt.Local())
+		fmt.Println("")
+	}
+	return nil
+}
+
+// requeueCommands runs failed commands again. Either
+// a command is identified by ID, or all failed
+// commands are requeued.
+func (c *Cli) requeueCommands(ctx *cli.Context) error {
+	dry := ctx.Bool("dry")
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := ctx.Args().Slice()
+	if ctx.Bool("failed") {
+		cmds, err := client.CommandsList(ctx.Context, url.Values{
+			"state": []string{store.CommandFailed},
+		})
+		if err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			ids = append(ids, cmd.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("require: <id> or --failed")
+	}
+
+	for _, id := range ids {
+		if dry {
+			fmt.Println("skipping requeue command (dry run):", id)
+			continue
+		}
+		cmd, err := client.CommandRequeue(ctx.Context, id)
+		if err != nil {
+			return err
+		}
+		fmt.Println("requeued command:", cmd.ID, cmd.Action)
+	}
+	return nil
+}
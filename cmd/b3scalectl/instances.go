@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// showInstances lists the b3scaled instances
+// serving the cluster
+func (c *Cli) showInstances(ctx *cli.Context) error {
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := client.Status(ctx.Context)
+	if err != nil {
+		return err
+	}
+	for _, s := range status.Instances {
+		health := "alive"
+		if s.IsStale() {
+			health = "stale"
+		}
+		leader := ""
+		if status.Leader != nil && status.Leader.Holder == s.ID {
+			leader = " (leader)"
+		}
+		fmt.Printf("%s%s\n", s.ID, leader)
+		fmt.Printf("  Hostname:\t %s\n", s.Hostname)
+		fmt.Printf("  Version:\t %s\t", s.Version)
+		fmt.Printf("  Build:\t %s\n", s.Build)
+		fmt.Printf("  StartedAt:\t %v\n", s.StartedAt.Local())
+		fmt.Printf("  Heartbeat:\t %v\t", s.HeartbeatAt.Local())
+		fmt.Printf("  (%s)\n", health)
+		fmt.Printf("  Commands:\t %d\n", s.CommandsProcessed)
+		if s.LastError != nil {
+			fmt.Printf("  LastError:\t %s\n", *s.LastError)
+			fmt.Printf("  LastErrorAt:\t %v\n", s.LastErrorAt.Local())
+		}
+		fmt.Println("")
+	}
+	return nil
+}
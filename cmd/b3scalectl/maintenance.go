@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// Time formats accepted for maintenance windows. Times
+// without a zone are in the local time.
+var maintenanceTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// parseMaintenanceTime parses the start, end or
+// deadline of a maintenance window
+func parseMaintenanceTime(value string) (time.Time, error) {
+	for _, layout := range maintenanceTimeFormats {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		"invalid time %q, use: YYYY-MM-DD HH:MM", value)
+}
+
+// setMaintenance schedules a maintenance window
+// for a backend
+func (c *Cli) setMaintenance(ctx *cli.Context) error {
+	dry := ctx.Bool("dry")
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("require: <host>")
+	}
+	host := ctx.Args().Get(0)
+
+	start, err := parseMaintenanceTime(ctx.String("start"))
+	if err != nil {
+		return err
+	}
+	end, err := parseMaintenanceTime(ctx.String("end"))
+	if err != nil {
+		return err
+	}
+	window := store.InitMaintenanceWindow(&store.MaintenanceWindow{
+		Mode:     ctx.String("mode"),
+		StartsAt: start,
+		EndsAt:   end,
+	})
+	if ctx.IsSet("deadline") {
+		deadline, err := parseMaintenanceTime(ctx.String("deadline"))
+		if err != nil {
+			return err
+		}
+		window.Deadline = &deadline
+	}
+
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	backend, err := getBackendByHost(ctx.Context, client, host)
+	if err != nil {
+		return err
+	}
+	if backend == nil {
+		return fmt.Errorf("backend not found")
+	}
+	window.BackendID = backend.ID
+
+	if err := window.Validate(); err != nil {
+		return err
+	}
+	if dry {
+		fmt.Println("skipped scheduling maintenance window")
+		return nil
+	}
+	window, err = client.MaintenanceWindowCreate(ctx.Context, window)
+	if err != nil {
+		return err
+	}
+	fmt.Println("scheduled maintenance window:", window.ID)
+	return nil
+}
+
+// showMaintenance displays the maintenance windows,
+// optionally of a single backend
+func (c *Cli) showMaintenance(ctx *cli.Context) error {
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if ctx.IsSet("state") {
+		query.Set("state", ctx.String("state"))
+	}
+	hosts := map[string]string{}
+	if ctx.NArg() > 0 {
+		backend, err := getBackendByHost(ctx.Context, client, ctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		if backend == nil {
+			return fmt.Errorf("backend not found")
+		}
+		query.Set("backend_id", backend.ID)
+		hosts[backend.ID] = backend.Backend.Host
+	} else {
+		backends, err := client.BackendsList(ctx.Context)
+		if err != nil {
+			return err
+		}
+		for _, b := range backends {
+			hosts[b.ID] = b.Backend.Host
+		}
+	}
+
+	windows, err := client.MaintenanceWindowsList(ctx.Context, query)
+	if err != nil {
+		return err
+	}
+	for _, w := range windows {
+		fmt.Printf("%s\n  Host:\t %s\n", w.ID, hosts[w.BackendID])
+		fmt.Printf("  Mode:\t %s\t", w.Mode)
+		fmt.Printf("  State:\t %s\n", w.State)
+		fmt.Printf("  Start:\t %v\n", w.StartsAt.Local())
+		fmt.Printf("  End:\t %v\n", w.EndsAt.Local())
+		if w.Deadline != nil {
+			fmt.Printf("  Deadline:\t %v\n", w.Deadline.Local())
+		}
+		fmt.Println("")
+	}
+	return nil
+}
+
+// deleteMaintenance removes a scheduled maintenance
+// window or ends an active one
+func (c *Cli) deleteMaintenance(ctx *cli.Context) error {
+	dry := ctx.Bool("dry")
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("require: <id>")
+	}
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	window, err := client.MaintenanceWindowRetrieve(
+		ctx.Context, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	if dry {
+		fmt.Println("skipping delete maintenance window (dry run)")
+		return nil
+	}
+	window, err = client.MaintenanceWindowDelete(ctx.Context, window)
+	if err != nil {
+		return err
+	}
+	if window.State == store.MaintenanceActive {
+		fmt.Println("maintenance window ends now")
+	} else {
+		fmt.Println("deleted maintenance window")
+	}
+	return nil
+}
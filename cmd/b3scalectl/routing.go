@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/http/api"
+)
+
+// explainRouting shows the candidate backends for a
+// hypothetical create request and why they were
+// eliminated or reordered.
+func (c *Cli) explainRouting(ctx *cli.Context) error {
+	// Args should be frontend key and params
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("require: <frontend key> [param=value ...]")
+	}
+	key := ctx.Args().Get(0)
+	params := bbb.Params{}
+	for _, arg := range ctx.Args().Slice()[1:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid param: %s, expected param=value", arg)
+		}
+		params[kv[0]] = kv[1]
+	}
+
+	client, err := apiClient(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := client.RoutingDryRun(ctx.Context, &api.RoutingDryRunRequest{
+		FrontendKey: key,
+		Params:      params,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(res.RuleTags) > 0 {
+		fmt.Println("Rule tags:", strings.Join(res.RuleTags, ", "))
+	}
+	if res.Error != "" {
+		fmt.Println("Error:", res.Error)
+	}
+	for _, be := range res.Backends {
+		rank := "-"
+		if be.Rank > 0 {
+			rank = fmt.Sprintf("#%d", be.Rank)
+		}
+		fmt.Printf("%s\t%s\t%s\tstress: %.2f\ttags: %s\n",
+			rank, be.ID, be.Host, be.Stress, strings.Join(be.Tags, ", "))
+		for _, d := range be.Decisions {
+			fmt.Printf("\t%s by %s: %s\n", d.Action, d.Middleware, d.Reason)
+		}
+	}
+	return nil
+}
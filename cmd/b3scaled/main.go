@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -36,6 +37,31 @@ func main() {
 	logFormat := config.EnvOpt(config.EnvLogFormat, config.EnvLogFormatDefault)
 	revProxyEnabled := config.IsEnabled(config.EnvOpt(
 		config.EnvReverseProxy, config.EnvReverseProxyDefault))
+	stressStrategyName := config.EnvOpt(
+		config.EnvStressStrategy, config.EnvStressStrategyDefault)
+	stressSchedule := config.EnvOpt(config.EnvStressStrategySchedule, "")
+
+	createAttemptsStr := config.EnvOpt(
+		config.EnvCreateAttempts, config.EnvCreateAttemptsDefault)
+
+	warmupStr := config.EnvOpt(
+		config.EnvBackendWarmup, config.EnvBackendWarmupDefault)
+	warmupCreatesStr := config.EnvOpt(
+		config.EnvBackendWarmupCreates, config.EnvBackendWarmupCreatesDefault)
+	latencyThresholdStr := config.EnvOpt(
+		config.EnvLatencyThreshold, config.EnvLatencyThresholdDefault)
+	largeMeetingSizeStr := config.EnvOpt(
+		config.EnvLargeMeetingSize, config.EnvLargeMeetingSizeDefault)
+	maxMeetingDurationStr := config.EnvOpt(
+		config.EnvMaxMeetingDuration, config.EnvMaxMeetingDurationDefault)
+	emptyMeetingTimeoutStr := config.EnvOpt(
+		config.EnvEmptyMeetingTimeout, config.EnvEmptyMeetingTimeoutDefault)
+	endInactiveFrontendMeetings := config.IsEnabled(config.EnvOpt(
+		config.EnvEndInactiveFrontendMeetings,
+		config.EnvEndInactiveFrontendMeetingsDefault))
+	commandConcurrencyStr := config.EnvOpt(config.EnvCommandConcurrency, "")
+	commandPollIntervalStr := config.EnvOpt(
+		config.EnvCommandPollInterval, config.EnvCommandPollIntervalDefault)
 
 	dbPoolSize, err := strconv.Atoi(dbPoolSizeStr)
 
@@ -80,14 +106,101 @@ func main() {
 		}
 	}
 
+	createAttempts, err := strconv.Atoi(createAttemptsStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvCreateAttempts)
+	}
+
+	warmup, err := time.ParseDuration(warmupStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvBackendWarmup)
+	}
+	warmupCreates, err := strconv.ParseUint(warmupCreatesStr, 10, 32)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvBackendWarmupCreates)
+	}
+
+	latencyThreshold, err := time.ParseDuration(latencyThresholdStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvLatencyThreshold)
+	}
+
+	largeMeetingSize, err := strconv.ParseUint(largeMeetingSizeStr, 10, 32)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvLargeMeetingSize)
+	}
+
+	maxMeetingDuration, err := time.ParseDuration(maxMeetingDurationStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvMaxMeetingDuration)
+	}
+	emptyMeetingTimeout, err := time.ParseDuration(emptyMeetingTimeoutStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvEmptyMeetingTimeout)
+	}
+
+	commandConcurrency, err := cluster.ParseCommandConcurrency(
+		commandConcurrencyStr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvCommandConcurrency)
+	}
+	commandPollInterval, err := time.ParseDuration(commandPollIntervalStr)
+	if err != nil || commandPollInterval <= 0 {
+		log.Fatal().Err(err).Msg("invalid value for " + config.EnvCommandPollInterval)
+	}
+
+	stressStrategy := cluster.GetStressStrategy(stressStrategyName)
+	if stressStrategy == nil {
+		log.Fatal().
+			Str("strategy", stressStrategyName).
+			Strs("available", cluster.StressStrategyNames()).
+			Msg("unknown stress strategy")
+	}
+	log.Info().
+		Str("strategy", stressStrategyName).
+		Msg("using stress strategy")
+
+	if stressSchedule != "" {
+		schedule, err := cluster.ParseStressSchedule(
+			stressSchedule, stressStrategy)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid stress strategy schedule")
+		}
+		stressStrategy = schedule
+		log.Info().
+			Str("schedule", stressSchedule).
+			Msg("using stress strategy schedule")
+	}
+
 	// Initialize cluster
-	ctrl := cluster.NewController()
+	ctrl := cluster.NewController(&cluster.ControllerOptions{
+		MaxMeetingDuration:  maxMeetingDuration,
+		EmptyMeetingTimeout: emptyMeetingTimeout,
+
+		EndInactiveFrontendMeetings: endInactiveFrontendMeetings,
+
+		CommandConcurrency:  commandConcurrency,
+		CommandPollInterval: commandPollInterval,
+		CommandMaxInFlight:  dbPoolSize / 4,
+
+		CreateAttempts: createAttempts,
+	})
 
 	// Create router and configure middlewares.
 	// IMPORTANT: The middlewares are executed in reverse order.
+	// The order of the backends is decided by the last sort:
+	// PreferredTags, then Latency, then SizeHint for large
+	// meetings, then the stress from SortLoad. The sorts are
+	// stable, so each sort only breaks the ties of the next.
 	router := cluster.NewRouter(ctrl)
-	router.Use(routing.SortLoad)
+	router.Use(routing.PreferredTags)
+	router.Use(routing.Latency(latencyThreshold))
+	router.Use(routing.SizeHint(uint(largeMeetingSize)))
+	router.Use(routing.SortLoad(stressStrategy))
+	router.Use(routing.SlowStart(warmup, uint(warmupCreates)))
+	router.Use(routing.Capacity)
 	router.Use(routing.RequiredTags)
+	router.Use(routing.RoutingRules)
 
 	// Start cluster request handler, and apply middlewares.
 	// IMPORTANT: The middlewares are executed in reverse order.
@@ -99,10 +212,12 @@ func main() {
 	gateway.Use(requests.MeetingsRequestHandler(
 		router, &requests.MeetingsHandlerOptions{
 			UseReverseProxy: revProxyEnabled,
+			CreateAttempts:  createAttempts,
 		}))
 
 	gateway.Use(requests.SetMetaFrontend())
 	gateway.Use(requests.SetDefaultPresentation())
+	gateway.Use(requests.FrontendQuotas())
 	gateway.Use(requests.SetCreateParams())
 	gateway.Use(requests.BindMeetingFrontend())
 	gateway.Use(requests.RewriteUniqueMeetingID())
@@ -111,7 +226,7 @@ func main() {
 	go ctrl.Start()
 
 	// Start HTTP interface
-	httpServer := http.NewServer("http", ctrl, gateway)
+	httpServer := http.NewServer("http", ctrl, gateway, router)
 	go httpServer.Start(listenHTTP)
 
 	<-quit
@@ -24,6 +24,12 @@ const (
 	ParamState     = "state"
 
 	ParamDisabledFeatures = "disabledFeatures"
+
+	ParamIsBreakout      = "isBreakout"
+	ParamParentMeetingID = "parentMeetingID"
+
+	ParamMaxParticipants = "maxParticipants"
+	ParamDuration        = "duration"
 )
 
 var (
@@ -83,6 +89,19 @@ func (p Params) MeetingID() (string, bool) {
 	return id, ok
 }
 
+// ParentMeetingID retrieves the internal meeting id of the
+// parent meeting, if the params are for a breakout room.
+func (p Params) ParentMeetingID() (string, bool) {
+	if strings.ToLower(p[ParamIsBreakout]) != "true" {
+		return "", false
+	}
+	id, ok := p[ParamParentMeetingID]
+	if !ok || id == "" {
+		return "", false
+	}
+	return id, true
+}
+
 // MeetingIDs interprets the MeetingsID parameter
 // as a comma separated set of meeting ids.
 func (p Params) MeetingIDs() ([]string, bool) {
@@ -63,6 +63,26 @@ func TestParamsGetMeetingID(t *testing.T) {
 	}
 }
 
+func TestParamsParentMeetingID(t *testing.T) {
+	p := Params{
+		"meetingID":       "breakout1",
+		"isBreakout":      "true",
+		"parentMeetingID": "parentInternalID",
+	}
+	id, ok := p.ParentMeetingID()
+	if !ok {
+		t.Error("expected parentMeetingID")
+	}
+	if id != "parentInternalID" {
+		t.Error("unexpected parentMeetingID:", id)
+	}
+
+	p["isBreakout"] = "false"
+	if id, ok := p.ParentMeetingID(); ok {
+		t.Error("did not expect parentMeetingID:", id)
+	}
+}
+
 func TestSign(t *testing.T) {
 	// We use the example from the api documentation.
 	// However as we encode our parameters with a deterministic
@@ -29,6 +29,10 @@ const (
 
 	// RetFailed is the failure return code
 	RetFailed = "FAILED"
+
+	// MsgKeyNotFound is the message key of a failed
+	// response, when the meeting does not exist.
+	MsgKeyNotFound = "notFound"
 )
 
 const (
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"net/http"
 	"net/url"
 	"time"
@@ -31,6 +30,10 @@ var (
 	// ErrRecordingNotFound indicates, that the recording
 	// state could not retrieved.
 	ErrRecordingNotFound = errors.New("recording could not be found")
+
+	// ErrMeetingNotCreated indicates, that the backend
+	// responded, but did not create the meeting.
+	ErrMeetingNotCreated = errors.New("meeting was not created on server")
 )
 
 // A Backend is a BigBlueButton instance in the cluster.
@@ -94,6 +97,197 @@ func (b *Backend) HasTags(tags []string) bool {
 	return true
 }
 
+// HasCapacity checks if the backend can accept new
+// meetings with respect to the limits in the settings.
+// A limit of 0 is considered unlimited.
+func (b *Backend) HasCapacity() bool {
+	s := b.state.Settings
+	if s.MaxMeetings > 0 &&
+		b.state.MeetingsCount >= uint(s.MaxMeetings) {
+		return false
+	}
+	if s.MaxAttendees > 0 &&
+		b.state.AttendeesCount >= uint(s.MaxAttendees) {
+		return false
+	}
+	if s.MaxVideoStreams > 0 &&
+		b.state.VideoStreamsCount >= uint(s.MaxVideoStreams) {
+		return false
+	}
+	return true
+}
+
+// AttendeesLimit is the maximum number of attendees
+// on the backend. If the backend has no limit, ok is false.
+func (b *Backend) AttendeesLimit() (limit uint, ok bool) {
+	if b.state.Settings.MaxAttendees <= 0 {
+		return 0, false
+	}
+	return uint(b.state.Settings.MaxAttendees), true
+}
+
+// AttendeesHeadroom is the number of attendees the backend
+// can take until the attendees limit is reached. If the
+// backend has no limit, ok is false.
+func (b *Backend) AttendeesHeadroom() (headroom uint, ok bool) {
+	limit := b.state.Settings.MaxAttendees
+	if limit <= 0 {
+		return 0, false
+	}
+	if b.state.AttendeesCount >= uint(limit) {
+		return 0, true
+	}
+	return uint(limit) - b.state.AttendeesCount, true
+}
+
+// AttendeesLoad is the number of attendees on the
+// backend, scaled with the load factor.
+func (b *Backend) AttendeesLoad() float64 {
+	return b.state.LoadFactor * float64(b.state.AttendeesCount)
+}
+
+// Latency is the median latency of the last node syncs.
+func (b *Backend) Latency() time.Duration {
+	return b.state.MedianLatency()
+}
+
+// IsWarmingUp checks if the backend became ready
+// within the warm-up period.
+func (b *Backend) IsWarmingUp(warmup time.Duration) bool {
+	return b.state.IsWarmingUp(warmup)
+}
+
+// RecentCreates is the number of meetings created on
+// the backend within the last minute, including meetings
+// which are about to be created.
+func (b *Backend) RecentCreates() uint {
+	return b.state.RecentCreatesCount + b.state.ReservationsCount
+}
+
+// Reserve will account for a meeting which is about
+// to be created on the backend, until the meeting is
+// created or the next node sync.
+func (b *Backend) Reserve(ctx context.Context, meetingID string) error {
+	conn := store.ConnectionFromContext(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := b.state.Reserve(ctx, tx, meetingID); err != nil {
+		return err
+	}
+	b.state.ReservationsCount++
+	return tx.Commit(ctx)
+}
+
+// ReserveRequest will reserve the backend for the
+// meeting in the request. Errors are only logged, as the
+// reservation only improves the routing decisions.
+func (b *Backend) ReserveRequest(ctx context.Context, req *bbb.Request) {
+	meetingID, ok := req.Params.MeetingID()
+	if !ok {
+		return
+	}
+	if err := b.Reserve(ctx, meetingID); err != nil {
+		log.Error().
+			Err(err).
+			Str("backend", b.Host()).
+			Str("meetingID", meetingID).
+			Msg("could not reserve backend")
+	}
+}
+
+// releaseReservation removes the reservation for a meeting.
+// Errors are only logged, as the reservation will be
+// cleared with the next node sync.
+func (b *Backend) releaseReservation(ctx context.Context, meetingID string) {
+	conn := store.ConnectionFromContext(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("release reservation")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := b.state.ReleaseReservation(ctx, tx, meetingID); err != nil {
+		log.Error().Err(err).Msg("release reservation")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("release reservation")
+	}
+}
+
+// setLastError updates the last error of the node in
+// the store. Errors are only logged.
+func (b *Backend) setLastError(ctx context.Context, errMsg string) {
+	conn := store.ConnectionFromContext(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("set last error")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := b.state.SetLastError(ctx, tx, errMsg); err != nil {
+		log.Error().Err(err).Msg("set last error")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("set last error")
+	}
+}
+
+// IsCircuitClosed checks if the circuit breaker of the
+// backend permits requests.
+func (b *Backend) IsCircuitClosed() bool {
+	return b.state.Circuit.Allow(time.Now().UTC())
+}
+
+// IsCircuitAvailable checks if the backend could receive
+// a request: The circuit is closed or a probe is possible.
+// Unlike AllowRequest, the probe is not claimed, so this
+// can be used while selecting backends.
+func (b *Backend) IsCircuitAvailable() bool {
+	now := time.Now().UTC()
+	return b.state.Circuit.Allow(now) || b.state.Circuit.CanProbe(now)
+}
+
+// AllowRequest checks if a request may be sent to the
+// backend. If the circuit is half open, only a single
+// probe request is permitted across all instances. This
+// must only be called for the backend actually contacted.
+// When routing is traced, the probe is not claimed.
+func (b *Backend) AllowRequest(ctx context.Context) bool {
+	if b.IsCircuitClosed() {
+		return true
+	}
+	if RoutingTraceFromContext(ctx) != nil {
+		return false
+	}
+	return b.claimCircuitProbe(ctx)
+}
+
+// do sends a request to the backend and records
+// the result in the circuit breaker.
+func (b *Backend) do(
+	ctx context.Context,
+	req *bbb.Request,
+) (bbb.Response, error) {
+	res, err := b.client.Do(ctx, req)
+	b.recordRequest(err == nil)
+	return res, err
+}
+
+// recordRequest adds the result of the request to
+// the circuit breaker. The results are aggregated and
+// written to the store periodically.
+func (b *Backend) recordRequest(success bool) {
+	recordCircuitResult(b.state.ID, success)
+}
+
 // GetBackends retrievs all backends from the store,
 // filterable with a query.
 func GetBackends(
@@ -137,14 +331,9 @@ func GetBackend(
 }
 
 // Stress calculates the current node load
+// using the default stress strategy.
 func (b *Backend) Stress() float64 {
-	f := b.state.LoadFactor
-
-	// Assume a base load of n attendees. This should come from
-	// some a config or should be set per frontend.
-	attendeeBaseLoad := 15.0
-	attendeeLoad := math.Max(attendeeBaseLoad, float64(b.state.AttendeesCount))
-	return f * (float64(b.state.MeetingsCount) + attendeeLoad)
+	return DefaultStress(b)
 }
 
 // refreshNodeState will fetch all meetings from the backend.
@@ -174,6 +363,10 @@ func (b *Backend) refreshNodeState(
 	}
 	defer tx.Rollback(ctx)
 
+	// Reservations made before the sync will be accounted
+	// for in the meetings count after the sync.
+	syncStart := time.Now().UTC()
+
 	// Measure latency
 	t0 := time.Now()
 	req := bbb.GetMeetingsRequest(bbb.Params{}).WithBackend(b.state.Backend)
@@ -210,7 +403,7 @@ func (b *Backend) refreshNodeState(
 	// Update state
 	b.state.SyncedAt = time.Now().UTC()
 	b.state.LastError = nil
-	b.state.Latency = latency
+	b.state.AddLatency(latency)
 	if b.state.AdminState == "ready" {
 		b.state.NodeState = "ready"
 	}
@@ -218,6 +411,9 @@ func (b *Backend) refreshNodeState(
 	if err := b.state.Save(ctx, tx); err != nil {
 		return err
 	}
+	if _, err := b.state.ClearReservations(ctx, tx, syncStart); err != nil {
+		return err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		log.Error().
@@ -364,15 +560,43 @@ func (b *Backend) Create(
 		req.Request.Header.Set("content-type", "application/xml")
 	}
 
+	// The meeting will be counted after the create,
+	// so the reservation is no longer required.
+	if meetingID, ok := req.Params.MeetingID(); ok {
+		defer b.releaseReservation(ctx, meetingID)
+	}
+
 	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
+		// The backend is not reachable. The failure is
+		// counted by the circuit breaker, the node state
+		// is left to the node sync.
+		b.recordRequest(false)
+		b.setLastError(ctx, "create: "+err.Error())
 		return nil, err
 	}
 	createRes := res.(*bbb.CreateResponse)
+	b.recordRequest(createRes.Meeting != nil)
 	if createRes.Meeting == nil {
 		log.Error().
+			Str("backend", b.Host()).
+			Str("messageKey", createRes.MessageKey).
 			Msg("create returned without a meeting")
-		return nil, fmt.Errorf("meeting was not created on server")
+		errMsg := fmt.Sprintf(
+			"create: %s: %s", createRes.MessageKey, createRes.Message)
+		b.setLastError(ctx, errMsg)
+		return nil, fmt.Errorf("%w: %s", ErrMeetingNotCreated, errMsg)
+	}
+
+	// The create response does not include the breakout
+	// information, so we take it from the request.
+	if parentID, ok := req.Params.ParentMeetingID(); ok {
+		createRes.Meeting.IsBreakout = true
+		if createRes.Meeting.Breakout == nil {
+			createRes.Meeting.Breakout = &bbb.Breakout{
+				ParentMeetingID: parentID,
+			}
+		}
 	}
 
 	conn := store.ConnectionFromContext(ctx)
@@ -386,19 +610,32 @@ func (b *Backend) Create(
 		return nil, err
 	}
 	if meetingState == nil {
-		_, err = b.state.CreateMeetingState(ctx, tx, req.Frontend, createRes.Meeting)
+		meetingState, err = b.state.CreateMeetingState(
+			ctx, tx, req.Frontend, createRes.Meeting)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		// Update state, associate with backend and frontend
+		// Update state, associate with backend and frontend.
+		// When the meeting was recovered, the backend changed.
 		meetingState.Meeting = createRes.Meeting
+		meetingState.BackendID = &b.state.ID
 		meetingState.SyncedAt = time.Now().UTC()
 		if err := meetingState.Save(ctx, tx); err != nil {
 			return nil, err
 		}
 	}
 
+	// Keep the create request for recovering the meeting
+	// in case the backend fails.
+	err = meetingState.SetCreateRequest(ctx, tx, &store.MeetingCreateRequest{
+		Params: req.Params,
+		Body:   req.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
@@ -443,7 +680,7 @@ func (b *Backend) JoinProxy(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.JoinResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -477,7 +714,7 @@ func (b *Backend) IsMeetingRunning(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.IsMeetingRunningResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -490,7 +727,7 @@ func (b *Backend) End(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.EndResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -502,7 +739,7 @@ func (b *Backend) GetMeetingInfo(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.GetMeetingInfoResponse, error) {
-	rep, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	rep, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -562,7 +799,7 @@ func (b *Backend) GetMeetings(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.GetMeetingsResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -574,7 +811,7 @@ func (b *Backend) GetDefaultConfigXML(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.GetDefaultConfigXMLResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -586,7 +823,7 @@ func (b *Backend) SetConfigXML(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.SetConfigXMLResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -598,7 +835,7 @@ func (b *Backend) PutRecordingTextTrack(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.PutRecordingTextTrackResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
@@ -610,7 +847,7 @@ func (b *Backend) GetRecordingTextTracks(
 	ctx context.Context,
 	req *bbb.Request,
 ) (*bbb.GetRecordingTextTracksResponse, error) {
-	res, err := b.client.Do(ctx, req.WithBackend(b.state.Backend))
+	res, err := b.do(ctx, req.WithBackend(b.state.Backend))
 	if err != nil {
 		return nil, err
 	}
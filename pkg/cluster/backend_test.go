@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	"github.com/b3scale/b3scale/pkg/store"
 )
@@ -89,3 +90,56 @@ func TestBackendHasTags(t *testing.T) {
 		t.Error("should not have tags foo")
 	}
 }
+
+func TestBackendHasCapacity(t *testing.T) {
+	be := &Backend{
+		state: &store.BackendState{
+			MeetingsCount:     10,
+			AttendeesCount:    100,
+			VideoStreamsCount: 20,
+		},
+	}
+	if !be.HasCapacity() {
+		t.Error("backend without limits should have capacity")
+	}
+
+	be.state.Settings.MaxMeetings = 11
+	be.state.Settings.MaxAttendees = 101
+	be.state.Settings.MaxVideoStreams = 21
+	if !be.HasCapacity() {
+		t.Error("backend should have capacity")
+	}
+
+	be.state.Settings.MaxAttendees = 100
+	if be.HasCapacity() {
+		t.Error("backend should not have capacity")
+	}
+
+	be.state.Settings.MaxAttendees = 0
+	be.state.Settings.MaxVideoStreams = 20
+	if be.HasCapacity() {
+		t.Error("backend should not have capacity")
+	}
+}
+
+func TestBackendIsCircuitClosed(t *testing.T) {
+	be := &Backend{
+		state: &store.BackendState{},
+	}
+	if !be.IsCircuitClosed() {
+		t.Error("circuit should be closed")
+	}
+
+	be.state.Circuit = store.CircuitBreaker{
+		State:    store.CircuitOpen,
+		OpenedAt: time.Now().UTC(),
+	}
+	if be.IsCircuitClosed() {
+		t.Error("circuit should be open")
+	}
+
+	be.state.Circuit.OpenedAt = time.Now().UTC().Add(-store.CircuitCooldown)
+	if be.IsCircuitClosed() {
+		t.Error("half open circuit should only permit a probe")
+	}
+}
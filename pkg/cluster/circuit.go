@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// CircuitFlushInterval is the interval in which the
+// aggregated request results are written to the
+// circuit breakers of the backends.
+const CircuitFlushInterval = 1 * time.Second
+
+// circuitResults counts the requests to a backend
+// since the last flush.
+type circuitResults struct {
+	requests uint
+	failures uint
+}
+
+// The request results are aggregated per instance, so
+// the circuit breaker row of a backend is not locked
+// for every request.
+var (
+	pendingCircuitResults     = map[string]*circuitResults{}
+	pendingCircuitResultsLock sync.Mutex
+)
+
+// recordCircuitResult adds the result of a request
+// to the pending results of the backend.
+func recordCircuitResult(backendID string, success bool) {
+	pendingCircuitResultsLock.Lock()
+	defer pendingCircuitResultsLock.Unlock()
+
+	results, ok := pendingCircuitResults[backendID]
+	if !ok {
+		results = &circuitResults{}
+		pendingCircuitResults[backendID] = results
+	}
+	results.requests++
+	if !success {
+		results.failures++
+	}
+}
+
+// takeCircuitResults returns and resets
+// the pending results.
+func takeCircuitResults() map[string]*circuitResults {
+	pendingCircuitResultsLock.Lock()
+	defer pendingCircuitResultsLock.Unlock()
+
+	results := pendingCircuitResults
+	pendingCircuitResults = map[string]*circuitResults{}
+	return results
+}
+
+// FlushCircuitResults writes the pending request
+// results to the circuit breakers of the backends.
+func FlushCircuitResults(ctx context.Context) {
+	for backendID, results := range takeCircuitResults() {
+		flushBackendCircuit(ctx, backendID, results)
+	}
+}
+
+// flushBackendCircuit updates the circuit breaker of
+// a backend. Errors are only logged.
+func flushBackendCircuit(
+	ctx context.Context,
+	backendID string,
+	results *circuitResults,
+) {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("flush circuit results")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	state := &store.BackendState{ID: backendID}
+	if err := state.RecordRequests(
+		ctx, tx, results.requests, results.failures); err != nil {
+		log.Error().Err(err).Msg("flush circuit results")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("flush circuit results")
+		return
+	}
+
+	if state.Circuit.State == store.CircuitOpen &&
+		state.Circuit.OpenedAt.After(time.Now().UTC().Add(-CircuitFlushInterval)) {
+		log.Warn().
+			Str("backendID", backendID).
+			Uint("requests", results.requests).
+			Uint("failures", results.failures).
+			Msg("backend circuit breaker opened")
+	}
+}
+
+// claimCircuitProbe tries to claim the single probe
+// request to a backend with a half open circuit.
+func (b *Backend) claimCircuitProbe(ctx context.Context) bool {
+	if !b.state.Circuit.CanProbe(time.Now().UTC()) {
+		return false
+	}
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("claim circuit probe")
+		return false
+	}
+	defer tx.Rollback(ctx)
+
+	claimed, err := b.state.ClaimCircuitProbe(ctx, tx)
+	if err != nil {
+		log.Error().Err(err).Msg("claim circuit probe")
+		return false
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("claim circuit probe")
+		return false
+	}
+	if claimed {
+		log.Info().
+			Str("backend", b.Host()).
+			Msg("sending probe request to backend with half open circuit")
+	}
+	return claimed
+}
+
+// flushCircuitResults acquires a connection
+// and flushes the pending results.
+func (c *Controller) flushCircuitResults() {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 10*CircuitFlushInterval)
+	defer cancel()
+
+	conn, err := store.Acquire(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not acquire connection")
+		return
+	}
+	defer conn.Release()
+	FlushCircuitResults(store.ContextWithConnection(ctx, conn))
+}
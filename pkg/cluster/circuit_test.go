@@ -0,0 +1,24 @@
+package cluster
+
+import (
+	"testing"
+)
+
+func TestRecordCircuitResult(t *testing.T) {
+	takeCircuitResults()
+
+	recordCircuitResult("b1", true)
+	recordCircuitResult("b1", false)
+	recordCircuitResult("b2", false)
+
+	results := takeCircuitResults()
+	if r := results["b1"]; r.requests != 2 || r.failures != 1 {
+		t.Error("unexpected results:", r)
+	}
+	if r := results["b2"]; r.requests != 1 || r.failures != 1 {
+		t.Error("unexpected results:", r)
+	}
+	if len(takeCircuitResults()) != 0 {
+		t.Error("results should be reset")
+	}
+}
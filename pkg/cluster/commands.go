@@ -4,6 +4,9 @@ package cluster
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/b3scale/b3scale/pkg/store"
@@ -15,9 +18,18 @@ const (
 	CmdUpdateNodeState     = "update_node_state"
 	CmdDecommissionBackend = "decommission_backend"
 
+	CmdApplyMaintenanceWindow = "apply_maintenance_window"
+
 	// Meetings
 	CmdUpdateMeetingState = "update_meeting_state"
 	CmdEndAllMeetings     = "end_all_meetings"
+	CmdEndMeeting         = "end_meeting"
+
+	CmdEndOrphanedMeeting = "end_orphaned_meeting"
+	CmdRecoverMeeting     = "recover_meeting"
+
+	// Frontends
+	CmdEndFrontendMeetings = "end_frontend_meetings"
 
 	// Maintenance
 	CmdCollectGarbage = "collect_garbage"
@@ -29,6 +41,44 @@ var (
 	ErrUnknownCommand = errors.New("command unknown")
 )
 
+// DefaultCommandConcurrency limits the number of commands
+// of an action, which are processed at the same time
+// across the cluster. Actions not listed are unlimited.
+var DefaultCommandConcurrency = map[string]int{
+	CmdUpdateNodeState:    8,
+	CmdUpdateMeetingState: 4,
+	CmdCollectGarbage:     1,
+}
+
+// ParseCommandConcurrency parses concurrency limits
+// of command actions like:
+//
+//	update_meeting_state=4,update_node_state=8
+//
+// A limit of 0 means unlimited.
+func ParseCommandConcurrency(s string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf(
+				"invalid concurrency limit %q, expected: action=limit", entry)
+		}
+		action := strings.TrimSpace(kv[0])
+		limit, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf(
+				"invalid concurrency limit for %s: %s", action, kv[1])
+		}
+		limits[action] = limit
+	}
+	return limits, nil
+}
+
 // DecommissionBackendRequest declares the removal
 // of a backend node from the cluster state.
 type DecommissionBackendRequest struct {
@@ -39,9 +89,32 @@ type DecommissionBackendRequest struct {
 // backend from the state.
 func DecommissionBackend(req *DecommissionBackendRequest) *store.Command {
 	return &store.Command{
-		Action:   CmdDecommissionBackend,
-		Params:   req,
-		Deadline: store.NextDeadline(10 * time.Minute),
+		Action:      CmdDecommissionBackend,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(10 * time.Minute),
+	}
+}
+
+// ApplyMaintenanceWindowRequest requests updating a
+// backend according to its maintenance window.
+type ApplyMaintenanceWindowRequest struct {
+	ID string `json:"id"` // the maintenance window id
+}
+
+// ApplyMaintenanceWindow disables or enables the backend
+// of a maintenance window and ends remaining meetings
+// at the deadline.
+func ApplyMaintenanceWindow(
+	req *ApplyMaintenanceWindowRequest,
+) *store.Command {
+	return &store.Command{
+		Action:      CmdApplyMaintenanceWindow,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
 	}
 }
 
@@ -55,6 +128,7 @@ type UpdateNodeStateRequest struct {
 func UpdateNodeState(req *UpdateNodeStateRequest) *store.Command {
 	return &store.Command{
 		Action:   CmdUpdateNodeState,
+		Priority: store.CommandPriorityNormal,
 		Params:   req,
 		Deadline: store.NextDeadline(10 * time.Minute),
 	}
@@ -71,6 +145,7 @@ func UpdateMeetingState(
 ) *store.Command {
 	return &store.Command{
 		Action:   CmdUpdateMeetingState,
+		Priority: store.CommandPriorityLow,
 		Params:   req,
 		Deadline: store.NextDeadline(10 * time.Minute),
 	}
@@ -86,9 +161,88 @@ type EndAllMeetingsRequest struct {
 // on a backend. This can be usefull to force decommissioning.
 func EndAllMeetings(req *EndAllMeetingsRequest) *store.Command {
 	return &store.Command{
-		Action:   CmdEndAllMeetings,
-		Params:   req,
-		Deadline: store.NextDeadline(5 * time.Minute),
+		Action:      CmdEndAllMeetings,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
+	}
+}
+
+// EndMeetingRequest contains parameters for the end
+// meeting command.
+type EndMeetingRequest struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// EndMeeting will send an end meeting api request to
+// a running meeting. The reason is kept with the command.
+func EndMeeting(req *EndMeetingRequest) *store.Command {
+	return &store.Command{
+		Action:      CmdEndMeeting,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
+	}
+}
+
+// EndOrphanedMeetingRequest contains parameters for
+// the end orphaned meeting command.
+type EndOrphanedMeetingRequest struct {
+	BackendID   string `json:"backend_id"`
+	MeetingID   string `json:"meeting_id"`
+	ModeratorPW string `json:"moderator_pw"`
+}
+
+// EndOrphanedMeeting will end a meeting on a backend,
+// where the create failed with an unknown outcome,
+// unless the meeting state is bound to the backend.
+func EndOrphanedMeeting(req *EndOrphanedMeetingRequest) *store.Command {
+	return &store.Command{
+		Action:      CmdEndOrphanedMeeting,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
+	}
+}
+
+// RecoverMeetingRequest contains parameters for
+// the recover meeting command.
+type RecoverMeetingRequest struct {
+	ID        string `json:"id"`
+	BackendID string `json:"backend_id"`
+}
+
+// RecoverMeeting will create a meeting, which is no
+// longer bound to a backend, on another backend.
+func RecoverMeeting(req *RecoverMeetingRequest) *store.Command {
+	return &store.Command{
+		Action:      CmdRecoverMeeting,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
+	}
+}
+
+// EndFrontendMeetingsRequest contains parameters for the
+// end frontend meetings command.
+type EndFrontendMeetingsRequest struct {
+	FrontendID string
+}
+
+// EndFrontendMeetings will send end meeting api requests to
+// all running meetings of a frontend on all backends.
+func EndFrontendMeetings(req *EndFrontendMeetingsRequest) *store.Command {
+	return &store.Command{
+		Action:      CmdEndFrontendMeetings,
+		Priority:    store.CommandPriorityHigh,
+		Params:      req,
+		MaxAttempts: store.DefaultCommandMaxAttempts,
+		Deadline:    store.NextDeadline(5 * time.Minute),
 	}
 }
 
@@ -96,6 +250,7 @@ func EndAllMeetings(req *EndAllMeetingsRequest) *store.Command {
 func CollectGarbage() *store.Command {
 	return &store.Command{
 		Action:   CmdCollectGarbage,
+		Priority: store.CommandPriorityLow,
 		Deadline: store.NextDeadline(5 * time.Minute),
 	}
 }
@@ -0,0 +1,26 @@
+package cluster
+
+import (
+	"testing"
+)
+
+func TestParseCommandConcurrency(t *testing.T) {
+	limits, err := ParseCommandConcurrency(
+		"update_meeting_state=2, end_all_meetings = 0,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits[CmdUpdateMeetingState] != 2 {
+		t.Error("unexpected limit:", limits[CmdUpdateMeetingState])
+	}
+	if limit, ok := limits[CmdEndAllMeetings]; !ok || limit != 0 {
+		t.Error("unexpected limit:", limit)
+	}
+
+	if _, err := ParseCommandConcurrency("update_node_state"); err == nil {
+		t.Error("expected an error for a missing limit")
+	}
+	if _, err := ParseCommandConcurrency("update_node_state=-1"); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
@@ -11,6 +11,7 @@ var (
 	backendsContextKey = requestContextKey(1)
 	backendContextKey  = requestContextKey(2)
 	frontendContextKey = requestContextKey(3)
+	traceContextKey    = requestContextKey(4)
 )
 
 // NewRequestContext create a new context
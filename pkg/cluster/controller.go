@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"sync"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog/log"
 
 	"github.com/b3scale/b3scale/pkg/bbb"
@@ -21,26 +23,108 @@ const (
 	// NodeSyncInterval is the amount of time after a backend
 	// node is considered stale and should be refreshed.
 	NodeSyncInterval = 20 * time.Second
+
+	// LeaderLeaseController is the name of the lease held
+	// by the instance scheduling the background tasks.
+	LeaderLeaseController = "controller"
+
+	// LeaderLeaseTTL is the amount of time after another
+	// instance takes over, if the leader does not renew
+	// the lease.
+	LeaderLeaseTTL = 30 * time.Second
 )
 
+// ControllerOptions configure the limits enforced
+// by the controller background tasks.
+type ControllerOptions struct {
+	// MaxMeetingDuration is the maximum time a meeting
+	// may run. 0 means unlimited.
+	MaxMeetingDuration time.Duration
+
+	// EmptyMeetingTimeout is the time after a meeting
+	// without attendees is ended. 0 means unlimited.
+	EmptyMeetingTimeout time.Duration
+
+	// EndInactiveFrontendMeetings ends all meetings
+	// of frontends which are not active.
+	EndInactiveFrontendMeetings bool
+
+	// CommandConcurrency overrides the concurrency limits
+	// of command actions. See DefaultCommandConcurrency.
+	CommandConcurrency map[string]int
+
+	// CommandPollInterval limits the frequency of
+	// checking the command queue.
+	CommandPollInterval time.Duration
+
+	// CommandMaxInFlight limits the number of commands
+	// processed at the same time by this instance.
+	// See store.DefaultCommandMaxInFlight.
+	CommandMaxInFlight int
+
+	// InstanceID identifies this instance when electing
+	// the leader. Defaults to hostname:pid.
+	InstanceID string
+
+	// CreateAttempts is the number of backends tried
+	// when recovering a meeting. See DefaultCreateAttempts.
+	CreateAttempts int
+}
+
+// NewInstanceID creates an identifier for this
+// instance from the hostname and the process id.
+func NewInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
 // The Controller interfaces with the state of the cluster
 // providing methods for retrieving cluster backends and
 // frontends.
 //
 // The controller subscribes to commands.
 type Controller struct {
-	cmds *store.CommandQueue
+	cmds   *store.CommandQueue
+	opts   *ControllerOptions
+	router *Router
 
 	lastStartBackground time.Time
+	isLeader            bool
 	mtx                 sync.Mutex
+
+	instance    *store.InstanceState
+	instanceMtx sync.Mutex
 }
 
 // NewController will initialize the cluster controller
 // with a database connection. A BBB client will be created
 // which will be used by the backend instances.
-func NewController() *Controller {
+func NewController(opts *ControllerOptions) *Controller {
+	if opts == nil {
+		opts = &ControllerOptions{}
+	}
+	if opts.InstanceID == "" {
+		opts.InstanceID = NewInstanceID()
+	}
+	if opts.CreateAttempts == 0 {
+		opts.CreateAttempts = DefaultCreateAttempts
+	}
+	cmds := store.NewCommandQueue()
+	for action, limit := range DefaultCommandConcurrency {
+		cmds.SetConcurrencyLimit(action, limit)
+	}
+	for action, limit := range opts.CommandConcurrency {
+		cmds.SetConcurrencyLimit(action, limit)
+	}
+	cmds.SetPollInterval(opts.CommandPollInterval)
+	cmds.SetMaxInFlight(opts.CommandMaxInFlight)
 	return &Controller{
-		cmds: store.NewCommandQueue(),
+		cmds:     cmds,
+		opts:     opts,
+		instance: newInstanceState(opts.InstanceID),
 	}
 }
 
@@ -51,6 +135,22 @@ func (c *Controller) Start() {
 	// Jitter startup in case multiple instances are spawned at the same time
 	time.Sleep(time.Duration(rand.Float64()) * time.Second) // 0 <= jitter < 1.0
 
+	// Write the request results to the circuit breakers
+	go func() {
+		for {
+			c.flushCircuitResults()
+			time.Sleep(CircuitFlushInterval)
+		}
+	}()
+
+	// Report the state of this instance
+	go func() {
+		for {
+			c.heartbeat()
+			time.Sleep(InstanceHeartbeatInterval)
+		}
+	}()
+
 	// Periodically start background tasks, even if they
 	// are not triggered through requests
 	go func() {
@@ -65,11 +165,12 @@ func (c *Controller) Start() {
 	// Controller Main Loop
 	for {
 		// Process commands from queue
-		if err := c.cmds.Receive(c.handleCommand); err != nil {
+		if err := c.cmds.Receive(c.execCommand); err != nil {
 			// We will only reach this code when waiting for
 			// commands fails. This can happen when the database
 			// is down. So, we log the error and wait a bit.
 			log.Error().Err(err).Msg("receive next command")
+			c.recordError(err)
 			time.Sleep(1.0 * time.Second)
 		}
 	}
@@ -100,6 +201,12 @@ func (c *Controller) StartBackground() {
 	defer conn.Release()
 	ctx = store.ContextWithConnection(ctx, conn)
 
+	// Only the leader schedules the background tasks,
+	// other instances just process the commands.
+	if !c.renewLeadership(ctx) {
+		return
+	}
+
 	// Dispatch loading of the backend state if the
 	// last sync was verly long.
 	if err := c.requestSyncStaleNodes(ctx); err != nil {
@@ -123,6 +230,29 @@ func (c *Controller) StartBackground() {
 		log.Error().Err(err).Msg("requestBackendDecommissions")
 	}
 
+	// Disable and enable backends in maintenance windows
+	if err := c.requestApplyMaintenanceWindows(ctx); err != nil {
+		log.Error().Err(err).Msg("requestApplyMaintenanceWindows")
+	}
+
+	// End meetings exceeding the maximum duration
+	// or which are empty for too long.
+	if err := c.requestEndExpiredMeetings(ctx); err != nil {
+		log.Error().Err(err).Msg("requestEndExpiredMeetings")
+	}
+
+	// Recover meetings no longer bound to a backend
+	if err := c.requestRecoverUnboundMeetings(ctx); err != nil {
+		log.Error().Err(err).Msg("requestRecoverUnboundMeetings")
+	}
+
+	// End meetings of deactivated frontends
+	if c.opts.EndInactiveFrontendMeetings {
+		if err := c.requestEndInactiveFrontendMeetings(ctx); err != nil {
+			log.Error().Err(err).Msg("requestEndInactiveFrontendMeetings")
+		}
+	}
+
 	// Do some cleaning like removing old state
 	if err := c.requestCollectGarbage(ctx); err != nil {
 		log.Error().Err(err).Msg("requestCollectGarbage")
@@ -135,6 +265,49 @@ func (c *Controller) StartBackground() {
 	}
 }
 
+// InstanceID returns the identifier of this
+// instance used in the leader election.
+func (c *Controller) InstanceID() string {
+	return c.opts.InstanceID
+}
+
+// renewLeadership acquires or renews the controller
+// lease. When the leader dies, another instance will
+// take over after the lease expired.
+func (c *Controller) renewLeadership(ctx context.Context) bool {
+	conn := store.ConnectionFromContext(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("renewLeadership")
+		return false
+	}
+	defer tx.Rollback(ctx)
+
+	leader, err := store.AcquireLeaderLease(
+		ctx, tx, LeaderLeaseController, c.opts.InstanceID, LeaderLeaseTTL)
+	if err != nil {
+		log.Error().Err(err).Msg("renewLeadership")
+		return false
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("renewLeadership")
+		return false
+	}
+
+	if leader && !c.isLeader {
+		log.Info().
+			Str("instance", c.opts.InstanceID).
+			Msg("acquired leadership, scheduling background tasks")
+	}
+	if !leader && c.isLeader {
+		log.Warn().
+			Str("instance", c.opts.InstanceID).
+			Msg("lost leadership, no longer scheduling background tasks")
+	}
+	c.isLeader = leader
+	return leader
+}
+
 // Command callback handler: Decode the operation and
 // run the command specific handler. As this is invoked
 // by the CommandQueue, these functions are allowed
@@ -148,6 +321,9 @@ func (c *Controller) handleCommand(
 	case CmdDecommissionBackend:
 		log.Debug().Str("cmd", CmdDecommissionBackend).Msg("EXEC")
 		return c.handleDecommissionBackend(ctx, cmd)
+	case CmdApplyMaintenanceWindow:
+		log.Debug().Str("cmd", CmdApplyMaintenanceWindow).Msg("EXEC")
+		return c.handleApplyMaintenanceWindow(ctx, cmd)
 	case CmdUpdateNodeState:
 		log.Debug().Str("cmd", CmdUpdateNodeState).Msg("EXEC")
 		return c.handleUpdateNodeState(ctx, cmd)
@@ -157,6 +333,18 @@ func (c *Controller) handleCommand(
 	case CmdEndAllMeetings:
 		log.Debug().Str("cmd", CmdEndAllMeetings).Msg("EXEC")
 		return c.handleEndAllMeetings(ctx, cmd)
+	case CmdEndMeeting:
+		log.Debug().Str("cmd", CmdEndMeeting).Msg("EXEC")
+		return c.handleEndMeeting(ctx, cmd)
+	case CmdEndOrphanedMeeting:
+		log.Debug().Str("cmd", CmdEndOrphanedMeeting).Msg("EXEC")
+		return c.handleEndOrphanedMeeting(ctx, cmd)
+	case CmdRecoverMeeting:
+		log.Debug().Str("cmd", CmdRecoverMeeting).Msg("EXEC")
+		return c.handleRecoverMeeting(ctx, cmd)
+	case CmdEndFrontendMeetings:
+		log.Debug().Str("cmd", CmdEndFrontendMeetings).Msg("EXEC")
+		return c.handleEndFrontendMeetings(ctx, cmd)
 	case CmdCollectGarbage:
 		log.Debug().Str("cmd", CmdCollectGarbage).Msg("EXEC")
 		return c.handleCollectGarbage(ctx)
@@ -225,6 +413,145 @@ func (c *Controller) handleDecommissionBackend(
 	return true, nil
 }
 
+// Command: ApplyMaintenanceWindow
+// Disables the backend at the start of the window and
+// enables it again at the end. In force mode, the remaining
+// meetings are ended when the deadline is reached.
+func (c *Controller) handleApplyMaintenanceWindow(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	req := &ApplyMaintenanceWindowRequest{}
+	if err := cmd.FetchParams(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Windows of the same backend are applied one after
+	// another: The backend is locked before the windows.
+	bstate, err := store.GetBackendState(ctx, tx, store.Q().
+		Where(`id = (
+			SELECT backend_id FROM maintenance_windows
+			 WHERE id = ?)`, req.ID).
+		Suffix("FOR UPDATE"))
+	if err != nil {
+		return nil, err
+	}
+	if bstate == nil {
+		return false, nil // window was removed
+	}
+	w, err := store.GetMaintenanceWindow(ctx, tx, store.Q().
+		Where("id = ?", req.ID).
+		Suffix("FOR UPDATE"))
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return false, nil // window was removed
+	}
+
+	now := time.Now().UTC()
+	next := w.NextState(now)
+
+	// Only a ready backend is disabled, and only the window
+	// which disabled the backend enables it again. If another
+	// window of the backend is in effect, it takes over.
+	// A backend decommissioned in the meantime is left alone.
+	disabled := w.DisabledBackend
+	adminState := bstate.AdminState
+	if w.State == store.MaintenanceScheduled &&
+		next == store.MaintenanceActive &&
+		adminState == "ready" {
+		adminState = "stopped"
+		w.DisabledBackend = true
+	}
+	if next == store.MaintenanceDone && w.DisabledBackend {
+		w.DisabledBackend = false
+		other, err := store.GetMaintenanceWindow(ctx, tx, store.Q().
+			Where("backend_id = ?", w.BackendID).
+			Where("id <> ?", w.ID).
+			Where("state <> ?", store.MaintenanceDone).
+			Where("starts_at <= ?", now).
+			Where("ends_at > ?", now).
+			OrderBy("ends_at DESC").
+			Limit(1))
+		if err != nil {
+			return nil, err
+		}
+		if other != nil {
+			other.DisabledBackend = true
+			if err := other.Save(ctx, tx); err != nil {
+				return nil, err
+			}
+		} else if adminState == "stopped" {
+			adminState = "ready"
+		}
+	}
+	if adminState != bstate.AdminState {
+		log.Info().
+			Str("backendID", bstate.ID).
+			Str("host", bstate.Backend.Host).
+			Str("window", w.ID).
+			Str("admin_state", adminState).
+			Msg("maintenance window")
+		bstate.AdminState = adminState
+		if err := bstate.Save(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	if next != w.State || disabled != w.DisabledBackend {
+		w.State = next
+		if err := w.Save(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if !w.DeadlineReached(now) {
+		return w.State, nil
+	}
+
+	// End the remaining meetings on the backend. Breakout
+	// rooms are ended with the parent meeting.
+	tx, err = store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	mstates, err := store.GetMeetingStates(ctx, tx, store.Q().
+		Where("meetings.backend_id = ?", w.BackendID).
+		Where("meetings.parent_id IS NULL"))
+	if err != nil {
+		return nil, err
+	}
+	tx.Rollback(ctx) // We should not block the connection any longer
+
+	failed := 0
+	for _, m := range mstates {
+		if err := endMeeting(ctx, m, "maintenance window"); err != nil {
+			log.Error().
+				Err(err).
+				Str("backendID", w.BackendID).
+				Str("meetingID", m.ID).
+				Msg("end maintenance window meeting")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return nil, fmt.Errorf(
+			"ending %d of %d meetings failed", failed, len(mstates))
+	}
+
+	return w.State, nil
+}
+
 // Command: UpdateNodeState
 func (c *Controller) handleUpdateNodeState(
 	ctx context.Context,
@@ -349,32 +676,236 @@ func (c *Controller) handleEndAllMeetings(
 	}
 	tx.Rollback(ctx) // We should not block the connection any longer
 
+	// Ended meetings are removed from the state, so
+	// a retry of the command only ends the remaining.
+	failed := 0
 	for _, m := range mstates {
-		log.Info().
-			Str("backendID", req.BackendID).
-			Str("meetingID", m.Meeting.MeetingID).
-			Msg("force end meeting")
-
-		req := bbb.EndRequest(bbb.Params{
-			"meetingID": m.Meeting.MeetingID,
-			"password":  m.Meeting.ModeratorPW,
-		})
-		res, err := backend.End(ctx, req)
-		if err != nil {
+		if err := endMeeting(ctx, m, "force end meeting"); err != nil {
+			log.Error().
+				Err(err).
+				Str("backendID", req.BackendID).
+				Str("meetingID", m.ID).
+				Msg("end meeting")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return nil, fmt.Errorf(
+			"ending %d of %d meetings failed", failed, len(mstates))
+	}
+
+	return true, nil
+}
+
+// handleEndMeeting sends an end request for a single
+// meeting and removes the meeting from the state.
+func (c *Controller) handleEndMeeting(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	req := &EndMeetingRequest{}
+	if err := cmd.FetchParams(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	mstate, err := store.GetMeetingStateByID(ctx, tx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mstate == nil {
+		return false, nil // meeting is already gone
+	}
+	tx.Rollback(ctx) // We should not block the connection any longer
+
+	if err := endMeeting(ctx, mstate, req.Reason); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// handleEndOrphanedMeeting ends a meeting on a backend,
+// which might have been created there, while the create
+// request failed. If the meeting state was bound to the
+// backend in the meantime, the meeting is kept.
+func (c *Controller) handleEndOrphanedMeeting(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	req := &EndOrphanedMeetingRequest{}
+	if err := cmd.FetchParams(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	mstate, err := store.GetMeetingStateByID(ctx, tx, req.MeetingID)
+	if err != nil {
+		return nil, err
+	}
+	if mstate != nil && mstate.BackendID != nil &&
+		*mstate.BackendID == req.BackendID {
+		return false, nil // the meeting is not orphaned
+	}
+	tx.Rollback(ctx)
+
+	backend, err := GetBackend(ctx, store.Q().
+		Where("id = ?", req.BackendID))
+	if err != nil {
+		return nil, err
+	}
+	if backend == nil {
+		return false, nil // backend is gone
+	}
+
+	params := bbb.Params{
+		bbb.ParamMeetingID: req.MeetingID,
+	}
+	if req.ModeratorPW != "" {
+		params["password"] = req.ModeratorPW
+	}
+	res, err := backend.End(ctx, bbb.EndRequest(params))
+	if err != nil {
+		return nil, err
+	}
+	if res.MessageKey == bbb.MsgKeyNotFound {
+		return false, nil // the meeting was not created
+	}
+	if res.Returncode != bbb.RetSuccess {
+		return nil, fmt.Errorf("end meeting failed: %s", res.MessageKey)
+	}
+
+	log.Warn().
+		Str("meetingID", req.MeetingID).
+		Str("backendID", req.BackendID).
+		Msg("ended orphaned meeting")
+
+	return true, nil
+}
+
+// handleEndFrontendMeetings will send an end request
+// for all meetings of a frontend on all backends.
+func (c *Controller) handleEndFrontendMeetings(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	req := &EndFrontendMeetingsRequest{}
+	if err := cmd.FetchParams(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Breakout rooms are ended with the parent meeting
+	mstates, err := store.GetMeetingStates(ctx, tx, store.Q().
+		Where("meetings.frontend_id = ?", req.FrontendID).
+		Where("meetings.parent_id IS NULL"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Meetings without a backend are being recovered and
+	// can not be ended on a backend. Removing the state
+	// stops the recovery.
+	bound := make([]*store.MeetingState, 0, len(mstates))
+	for _, m := range mstates {
+		if m.BackendID != nil {
+			bound = append(bound, m)
+			continue
+		}
+		if err := store.DeleteMeetingStateByID(ctx, tx, m.ID); err != nil {
 			return nil, err
 		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 
-		if res.Returncode != bbb.RetSuccess {
+	// End as many meetings as possible, even if
+	// a backend fails.
+	failed := 0
+	for _, m := range bound {
+		if err := endMeeting(ctx, m, "end frontend meetings"); err != nil {
 			log.Error().
-				Str("meetingID", m.Meeting.MeetingID).
-				Str("msg", res.Message).
-				Str("msgKey", res.MessageKey).
-				Msg("end meeting failed")
-			return nil, fmt.Errorf("end meeting failed: %s", res.MessageKey)
+				Err(err).
+				Str("frontendID", req.FrontendID).
+				Str("meetingID", m.ID).
+				Msg("end frontend meeting")
+			failed++
 		}
 	}
+	if failed > 0 {
+		return nil, fmt.Errorf(
+			"ending %d of %d meetings failed", failed, len(bound))
+	}
 
-	return true, nil
+	return len(mstates), nil
+}
+
+// endMeeting sends an end request to the backend of the
+// meeting and removes the meeting from the state, so it
+// is not ended again before the next node sync.
+func endMeeting(
+	ctx context.Context,
+	mstate *store.MeetingState,
+	reason string,
+) error {
+	backend, err := GetBackend(ctx, store.Q().
+		Where("id = ?", mstate.BackendID))
+	if err != nil {
+		return err
+	}
+	if backend == nil {
+		return fmt.Errorf("backend not found")
+	}
+
+	log.Info().
+		Str("meetingID", mstate.ID).
+		Str("backendID", backend.ID()).
+		Str("reason", reason).
+		Msg("end meeting")
+
+	res, err := backend.End(ctx, bbb.EndRequest(bbb.Params{
+		bbb.ParamMeetingID: mstate.Meeting.MeetingID,
+		"password":         mstate.Meeting.ModeratorPW,
+	}))
+	if err != nil {
+		return err
+	}
+	// The meeting might already be gone, e.g. when
+	// a previous attempt ended it.
+	notFound := res.MessageKey == bbb.MsgKeyNotFound
+	if res.Returncode != bbb.RetSuccess && !notFound {
+		log.Error().
+			Str("meetingID", mstate.ID).
+			Str("msg", res.Message).
+			Str("msgKey", res.MessageKey).
+			Msg("end meeting failed")
+		return fmt.Errorf("end meeting failed: %s", res.MessageKey)
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := store.DeleteMeetingStateByID(ctx, tx, mstate.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // handleCollectGarbage will do maintenance tasks
@@ -397,7 +928,21 @@ func (c *Controller) handleCollectGarbage(
 		ctx, tx, th); err != nil {
 		return nil, err
 	}
+	tx.Rollback(ctx)
 
+	// Remove instances which are gone
+	tx, err = store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if err := store.RemoveStaleInstances(
+		ctx, tx, time.Now().UTC().Add(-InstanceExpiry)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return true, nil
 }
 
@@ -557,6 +1102,206 @@ func (c *Controller) requestCollectGarbage(
 	return tx.Commit(ctx)
 }
 
+// requestApplyMaintenanceWindows dispatches an apply
+// maintenance window command for all windows which start
+// or end, and for windows in force mode with meetings
+// remaining on the backend after the deadline. Windows
+// with a pending command are skipped.
+func (c *Controller) requestApplyMaintenanceWindows(
+	ctx context.Context,
+) error {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+	windows, err := store.GetMaintenanceWindows(ctx, tx, store.Q().
+		Where(sq.Or{
+			sq.And{
+				sq.Eq{"state": store.MaintenanceScheduled},
+				sq.LtOrEq{"starts_at": now},
+			},
+			sq.And{
+				sq.Eq{"state": store.MaintenanceActive},
+				sq.LtOrEq{"ends_at": now},
+			},
+			sq.And{
+				sq.Eq{"state": store.MaintenanceActive},
+				sq.Eq{"mode": store.MaintenanceForce},
+				sq.Expr("COALESCE(deadline, starts_at) <= ?", now),
+				sq.Expr(`EXISTS (
+					SELECT 1 FROM meetings
+					 WHERE meetings.backend_id = maintenance_windows.backend_id)`),
+			},
+		}).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM commands
+			 WHERE commands.action = ?
+			   AND commands.state = ?
+			   AND commands.params->>'id' = maintenance_windows.id::text)`,
+			CmdApplyMaintenanceWindow, store.CommandRequested))
+	if err != nil {
+		return err
+	}
+
+	for _, w := range windows {
+		log.Info().
+			Str("cmd", CmdApplyMaintenanceWindow).
+			Str("backendID", w.BackendID).
+			Str("window", w.ID).
+			Msg("DISPATCH")
+		if err := store.QueueCommand(ctx, tx,
+			ApplyMaintenanceWindow(&ApplyMaintenanceWindowRequest{
+				ID: w.ID,
+			})); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// requestEndExpiredMeetings dispatches an end meeting
+// command for all meetings exceeding the maximum duration
+// of the cluster or the frontend, or which are empty
+// longer than the timeout.
+func (c *Controller) requestEndExpiredMeetings(
+	ctx context.Context,
+) error {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	frontends, err := store.GetFrontendStates(ctx, tx, store.Q())
+	if err != nil {
+		return err
+	}
+	settings := make(map[string]*store.FrontendSettings, len(frontends))
+	for _, f := range frontends {
+		settings[f.ID] = &f.Settings
+	}
+
+	// Breakout rooms have their own duration and are
+	// ended with the parent meeting. Meetings without a
+	// backend are being recovered and can not be ended.
+	// Meetings where ending was already requested are skipped.
+	mstates, err := store.GetMeetingStates(ctx, tx, store.Q().
+		Where("meetings.parent_id IS NULL").
+		Where("meetings.backend_id IS NOT NULL").
+		Where(`NOT EXISTS (
+			SELECT 1 FROM commands
+			 WHERE commands.action = ?
+			   AND commands.state = ?
+			   AND commands.params->>'id' = meetings.id)`,
+			CmdEndMeeting, store.CommandRequested))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, m := range mstates {
+		var fs *store.FrontendSettings
+		if m.FrontendID != nil {
+			fs = settings[*m.FrontendID]
+		}
+		reason, expired := c.meetingExpired(m, fs, now)
+		if !expired {
+			continue
+		}
+		log.Info().
+			Str("cmd", CmdEndMeeting).
+			Str("meetingID", m.ID).
+			Str("reason", reason).
+			Msg("DISPATCH")
+		if err := store.QueueCommand(ctx, tx,
+			EndMeeting(&EndMeetingRequest{
+				ID:     m.ID,
+				Reason: reason,
+			})); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// requestEndInactiveFrontendMeetings dispatches an end
+// frontend meetings command for each inactive frontend
+// with running meetings, unless it was already requested.
+func (c *Controller) requestEndInactiveFrontendMeetings(
+	ctx context.Context,
+) error {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	frontends, err := store.GetFrontendStates(ctx, tx, store.Q().
+		Where("frontends.active = ?", false).
+		Where(`EXISTS (
+			SELECT 1 FROM meetings
+			 WHERE meetings.frontend_id = frontends.id
+			   AND meetings.parent_id IS NULL)`).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM commands
+			 WHERE commands.action = ?
+			   AND commands.state = ?
+			   AND commands.params->>'FrontendID' = frontends.id::text)`,
+			CmdEndFrontendMeetings, store.CommandRequested))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range frontends {
+		log.Info().
+			Str("cmd", CmdEndFrontendMeetings).
+			Str("frontend", f.Frontend.Key).
+			Msg("DISPATCH")
+		if err := store.QueueCommand(ctx, tx,
+			EndFrontendMeetings(&EndFrontendMeetingsRequest{
+				FrontendID: f.ID,
+			})); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// meetingExpired checks if the meeting exceeds the
+// maximum duration or is empty longer than the timeout.
+// The maximum duration of the frontend applies, if it
+// is shorter than the maximum duration of the cluster.
+func (c *Controller) meetingExpired(
+	m *store.MeetingState,
+	settings *store.FrontendSettings,
+	now time.Time,
+) (string, bool) {
+	maxDuration := c.opts.MaxMeetingDuration
+	if settings != nil && settings.MaxMeetingDuration > 0 {
+		d := time.Duration(settings.MaxMeetingDuration) * time.Minute
+		if maxDuration <= 0 || d < maxDuration {
+			maxDuration = d
+		}
+	}
+	if maxDuration > 0 && now.Sub(m.CreatedAt) > maxDuration {
+		return "maximum duration of " + maxDuration.String() + " exceeded", true
+	}
+
+	timeout := c.opts.EmptyMeetingTimeout
+	if timeout > 0 && m.EmptySince != nil &&
+		now.Sub(*m.EmptySince) > timeout {
+		return "empty for more than " + timeout.String(), true
+	}
+
+	return "", false
+}
+
 // warnOfflineBackends iterates through all unlocked
 // backends and warns the user that there are backends offline
 func (c *Controller) warnOfflineBackends(ctx context.Context) error {
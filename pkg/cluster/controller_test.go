@@ -2,9 +2,58 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	_ "github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/b3scale/b3scale/pkg/store"
 )
 
 func TestGetBackend(t *testing.T) {
 }
+
+func TestControllerMeetingExpired(t *testing.T) {
+	now := time.Now().UTC()
+	emptySince := now.Add(-20 * time.Minute)
+	c := NewController(&ControllerOptions{
+		MaxMeetingDuration:  12 * time.Hour,
+		EmptyMeetingTimeout: 30 * time.Minute,
+	})
+
+	m := &store.MeetingState{
+		CreatedAt:  now.Add(-5 * time.Hour),
+		EmptySince: &emptySince,
+	}
+	if reason, ok := c.meetingExpired(m, nil, now); ok {
+		t.Error("unexpected expired:", reason)
+	}
+
+	// The frontend limit is shorter
+	settings := &store.FrontendSettings{
+		MaxMeetingDuration: 240,
+	}
+	if _, ok := c.meetingExpired(m, settings, now); !ok {
+		t.Error("expected meeting to exceed frontend limit")
+	}
+
+	// The cluster limit is shorter
+	settings.MaxMeetingDuration = 24 * 60
+	m.CreatedAt = now.Add(-13 * time.Hour)
+	if _, ok := c.meetingExpired(m, settings, now); !ok {
+		t.Error("expected meeting to exceed cluster limit")
+	}
+
+	// Empty meeting
+	m.CreatedAt = now.Add(-1 * time.Hour)
+	emptySince = now.Add(-31 * time.Minute)
+	if _, ok := c.meetingExpired(m, settings, now); !ok {
+		t.Error("expected empty meeting to expire")
+	}
+
+	// Unlimited
+	c = NewController(nil)
+	m.CreatedAt = now.Add(-100 * time.Hour)
+	if reason, ok := c.meetingExpired(m, nil, now); ok {
+		t.Error("unexpected expired:", reason)
+	}
+}
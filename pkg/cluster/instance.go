@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/config"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+const (
+	// InstanceHeartbeatInterval is the interval in which
+	// an instance reports its state.
+	InstanceHeartbeatInterval = 10 * time.Second
+
+	// InstanceExpiry is the amount of time after a
+	// stale instance is removed.
+	InstanceExpiry = 10 * time.Minute
+)
+
+// newInstanceState creates the state of this instance
+func newInstanceState(id string) *store.InstanceState {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &store.InstanceState{
+		ID:        id,
+		Hostname:  hostname,
+		Version:   config.Version,
+		Build:     config.Build,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// execCommand invokes the command handler and
+// tracks the processed commands and errors.
+func (c *Controller) execCommand(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	result, err := c.handleCommand(ctx, cmd)
+
+	c.instanceMtx.Lock()
+	c.instance.CommandsProcessed++
+	c.instanceMtx.Unlock()
+	if err != nil {
+		c.recordError(err)
+	}
+
+	return result, err
+}
+
+// recordError sets the last error of the instance
+func (c *Controller) recordError(err error) {
+	c.instanceMtx.Lock()
+	defer c.instanceMtx.Unlock()
+
+	msg := err.Error()
+	now := time.Now().UTC()
+	c.instance.LastError = &msg
+	c.instance.LastErrorAt = &now
+}
+
+// heartbeat reports the state of this instance
+func (c *Controller) heartbeat() {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), InstanceHeartbeatInterval)
+	defer cancel()
+
+	// Copy the current state
+	c.instanceMtx.Lock()
+	state := *c.instance
+	c.instanceMtx.Unlock()
+
+	conn, err := store.Acquire(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not acquire connection")
+		return
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("instance heartbeat")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := state.Heartbeat(ctx, tx); err != nil {
+		log.Error().Err(err).Msg("instance heartbeat")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("instance heartbeat")
+	}
+}
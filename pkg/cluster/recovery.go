@@ -0,0 +1,286 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// DefaultCreateAttempts is the number of backends
+// tried when a meeting is recovered.
+const DefaultCreateAttempts = 3
+
+// UnboundMeetingExpiry is the time after which a meeting
+// without a backend, which can not be recovered, is removed.
+const UnboundMeetingExpiry = 24 * time.Hour
+
+// CreateOnBackends tries the candidates in order,
+// until the meeting was created. At most attempts
+// backends are tried. Backends with a half open circuit
+// are skipped, unless the probe can be claimed.
+func CreateOnBackends(
+	ctx context.Context,
+	req *bbb.Request,
+	backends []*Backend,
+	attempts int,
+) (*bbb.CreateResponse, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for _, backend := range backends {
+		if attempts == 0 {
+			break
+		}
+		// The probe of a half open circuit is only
+		// claimed for the backend contacted.
+		if !backend.AllowRequest(ctx) {
+			continue
+		}
+		attempts--
+
+		backend.ReserveRequest(ctx, req)
+		var res *bbb.CreateResponse
+		res, err = backend.Create(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+		log.Warn().
+			Err(err).
+			Str("backend", backend.Host()).
+			Msg("create failed on backend")
+
+		// The backend might have created the meeting, even
+		// though the request failed, e.g. with a timeout.
+		if !errors.Is(err, ErrMeetingNotCreated) {
+			queueEndOrphanedMeeting(backend.ID(), req)
+		}
+	}
+	if err == nil {
+		err = ErrNoBackendAvailable
+	}
+	return nil, err
+}
+
+// queueEndOrphanedMeeting requests ending the meeting
+// on a backend, where the outcome of the create is unknown.
+// As the request context might be done, a new context
+// is used.
+func queueEndOrphanedMeeting(
+	backendID string,
+	req *bbb.Request,
+) {
+	meetingID, ok := req.Params.MeetingID()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := store.Acquire(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not acquire connection")
+		return
+	}
+	defer conn.Release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("queueEndOrphanedMeeting")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	cmd := EndOrphanedMeeting(&EndOrphanedMeetingRequest{
+		BackendID:   backendID,
+		MeetingID:   meetingID,
+		ModeratorPW: req.Params["moderatorPW"],
+	})
+	if err := store.QueueCommand(ctx, tx, cmd); err != nil {
+		log.Error().Err(err).Msg("queueEndOrphanedMeeting")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("queueEndOrphanedMeeting")
+	}
+}
+
+// handleRecoverMeeting recreates a meeting claimed for
+// recovery from the stored create request on a healthy
+// backend. The meeting is then ended on the previous
+// backend, in case the backend comes back. When the last
+// attempt fails, the meeting is bound to the previous
+// backend again, so the recovery can be requested again.
+func (c *Controller) handleRecoverMeeting(
+	ctx context.Context,
+	cmd *store.Command,
+) (interface{}, error) {
+	req := &RecoverMeetingRequest{}
+	if err := cmd.FetchParams(ctx, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	mstate, err := store.GetMeetingStateByID(ctx, tx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mstate == nil || mstate.BackendID != nil {
+		return false, nil // meeting is gone or was recovered
+	}
+	if mstate.CreateRequest == nil {
+		return nil, fmt.Errorf("meeting has no create request")
+	}
+	fstate, err := mstate.GetFrontendState(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	tx.Rollback(ctx)
+
+	createReq := bbb.CreateRequest(
+		mstate.CreateRequest.Params,
+		mstate.CreateRequest.Body)
+	frontendKey := ""
+	if fstate != nil {
+		createReq = createReq.WithFrontend(fstate.Frontend)
+		frontendKey = fstate.Frontend.Key
+	}
+
+	err = c.recoverMeeting(ctx, createReq, frontendKey, mstate.ID)
+	if err == nil {
+		if req.BackendID != "" {
+			queueEndOrphanedMeeting(req.BackendID, createReq)
+		}
+		return true, nil
+	}
+
+	log.Error().
+		Err(err).
+		Str("meetingID", mstate.ID).
+		Int("attempt", cmd.Attempts).
+		Msg("could not recover meeting")
+	if cmd.Attempts >= cmd.MaxAttempts && req.BackendID != "" {
+		restoreMeetingBackend(mstate, req.BackendID)
+	}
+	return nil, err
+}
+
+// recoverMeeting creates the meeting while holding
+// the create lock, so it does not race with a create.
+func (c *Controller) recoverMeeting(
+	ctx context.Context,
+	req *bbb.Request,
+	frontendKey string,
+	meetingID string,
+) error {
+	lockCtx, cancel := context.WithTimeout(ctx, store.MeetingLockTimeout)
+	defer cancel()
+	lock, err := store.AcquireMeetingLock(lockCtx, frontendKey, meetingID)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if c.router == nil {
+		return ErrNoBackendAvailable
+	}
+	backends, err := c.router.SelectBackends(ctx, req)
+	if err != nil {
+		return err
+	}
+	_, err = CreateOnBackends(ctx, req, backends, c.opts.CreateAttempts)
+	return err
+}
+
+// restoreMeetingBackend binds the meeting to the previous
+// backend again. As the context of the command might
+// be done, a new context is used.
+func restoreMeetingBackend(
+	mstate *store.MeetingState,
+	backendID string,
+) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := store.Acquire(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not acquire connection")
+		return
+	}
+	defer conn.Release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("restoreMeetingBackend")
+		return
+	}
+	defer tx.Rollback(ctx)
+	if _, err := mstate.RebindBackend(ctx, tx, nil, &backendID); err != nil {
+		log.Error().Err(err).Msg("restoreMeetingBackend")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("restoreMeetingBackend")
+	}
+}
+
+// requestRecoverUnboundMeetings reaps meetings which are
+// not bound to a backend and are not being recovered:
+// The recovery is requested again, or the meeting is
+// removed if it can not be recovered and was not synced
+// for the UnboundMeetingExpiry.
+func (c *Controller) requestRecoverUnboundMeetings(
+	ctx context.Context,
+) error {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	mstates, err := store.GetMeetingStates(ctx, tx, store.Q().
+		Where("meetings.backend_id IS NULL").
+		Where(`(meetings.create_request IS NOT NULL
+			 OR meetings.synced_at < ?)`,
+			time.Now().UTC().Add(-UnboundMeetingExpiry)).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM commands
+			 WHERE commands.action = ?
+			   AND commands.state = ?
+			   AND commands.params->>'id' = meetings.id)`,
+			CmdRecoverMeeting, store.CommandRequested))
+	if err != nil {
+		return err
+	}
+	for _, m := range mstates {
+		if m.CreateRequest == nil {
+			log.Warn().
+				Str("meetingID", m.ID).
+				Msg("removing expired unbound meeting without create request")
+			if err := store.DeleteMeetingStateByID(ctx, tx, m.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		log.Info().
+			Str("meetingID", m.ID).
+			Msg("requesting recovery of unbound meeting")
+		if err := store.QueueCommand(ctx, tx, RecoverMeeting(
+			&RecoverMeetingRequest{
+				ID: m.ID,
+			})); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,17 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
+
+func TestCreateOnBackendsNoBackends(t *testing.T) {
+	req := bbb.CreateRequest(bbb.Params{"meetingID": "m1"}, nil)
+	_, err := CreateOnBackends(context.Background(), req, nil, 3)
+	if !errors.Is(err, ErrNoBackendAvailable) {
+		t.Error("unexpected error:", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog/log"
 
 	"github.com/b3scale/b3scale/pkg/bbb"
@@ -22,6 +23,21 @@ var (
 	// available for creating a meeting.
 	ErrNoBackendAvailable = errors.New("no free backend availble for meeting")
 
+	// ErrNoBackendCapacity indicates that there are backends
+	// available for creating a meeting, but all of them
+	// reached their capacity limits.
+	ErrNoBackendCapacity = errors.New("no backend with free capacity available")
+
+	// ErrParentMeetingUnknown indicates that a breakout room
+	// should be created, but the parent meeting is not known
+	// to the cluster.
+	ErrParentMeetingUnknown = errors.New("parent meeting of breakout room is unknown")
+
+	// ErrParentBackendUnavailable indicates that a breakout
+	// room should be created, but the backend of the parent
+	// meeting does not accept requests.
+	ErrParentBackendUnavailable = errors.New("backend of parent meeting is not available")
+
 	// ErrMeetingIDMissing indicates that there is a meetingID
 	// expected to be in the requests params, but it is missing.
 	ErrMeetingIDMissing = errors.New("meetingID missing from request")
@@ -43,10 +59,14 @@ type Router struct {
 //
 // The middleware chain should only subtract backends.
 func NewRouter(ctrl *Controller) *Router {
-	return &Router{
+	r := &Router{
 		ctrl:       ctrl,
 		middleware: nilHandler,
 	}
+	if ctrl != nil {
+		ctrl.router = r
+	}
+	return r
 }
 
 // The nil handler is the end of the middleware chain.
@@ -64,14 +84,33 @@ func (r *Router) Use(middleware RouterMiddleware) {
 	r.middleware = middleware(r.middleware)
 }
 
-// SelectBackend will apply the routing middleware
+// SelectBackends will apply the routing middleware
 // chain to a given request with all ready nodes in
 // the cluster where the admin state is also ready.
-// Selecting a backend will fail if no backends are available
+// The backends are returned in the order of preference.
+// Selecting backends will fail if no backends are available
 // as routing targets.
-func (r *Router) SelectBackend(
+func (r *Router) SelectBackends(
 	ctx context.Context, req *bbb.Request,
-) (*Backend, error) {
+) ([]*Backend, error) {
+	// Breakout rooms must be created on the backend
+	// of the parent meeting.
+	if _, ok := req.Params.ParentMeetingID(); ok {
+		parent, err := r.LookupParentBackend(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		candidates := []*Backend{parent}
+		TraceCandidates(ctx, candidates)
+		available := filterParentAvailable(candidates)
+		TraceFilter(ctx, "parent_backend", candidates, available,
+			"backend of parent meeting is not available")
+		if len(available) == 0 {
+			return nil, ErrParentBackendUnavailable
+		}
+		return available, nil
+	}
+
 	// Filter backends and only accept state active,
 	// and where the node agent is active on the host.
 	// Also we exclude stopped nodes.
@@ -83,16 +122,66 @@ func (r *Router) SelectBackend(
 	if err != nil {
 		return nil, err
 	}
-	backends, err = r.middleware(ctx, backends, req)
+	TraceCandidates(ctx, backends)
+	closed := filterCircuitClosed(backends)
+	TraceFilter(ctx, "circuit_breaker", backends, closed,
+		"circuit breaker is open")
+	backends, err = r.middleware(ctx, closed, req)
 	if err != nil {
 		return nil, err
 	}
 	if len(backends) == 0 {
 		return nil, ErrNoBackendAvailable
 	}
+	return backends, nil
+}
+
+// filterParentAvailable removes the backends which do
+// not accept requests: The node must be ready and
+// enabled and the circuit breaker must not be open.
+func filterParentAvailable(backends []*Backend) []*Backend {
+	filtered := make([]*Backend, 0, len(backends))
+	for _, be := range backends {
+		if !be.state.IsNodeReady() || be.state.AdminState != "ready" {
+			continue
+		}
+		if !be.IsCircuitAvailable() {
+			continue
+		}
+		filtered = append(filtered, be)
+	}
+	return filtered
+}
+
+// filterCircuitClosed removes all backends where
+// the circuit breaker is open. A backend with a half
+// open circuit is kept, if a probe is possible. The
+// probe is claimed when the backend is contacted.
+func filterCircuitClosed(backends []*Backend) []*Backend {
+	filtered := make([]*Backend, 0, len(backends))
+	for _, be := range backends {
+		if be.IsCircuitAvailable() {
+			filtered = append(filtered, be)
+		}
+	}
+	return filtered
+}
+
+// SelectBackend will select the preferred backend
+// for a request. See SelectBackends.
+func (r *Router) SelectBackend(
+	ctx context.Context, req *bbb.Request,
+) (*Backend, error) {
+	backends, err := r.SelectBackends(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use first backend
-	return backends[0], nil
+	// Use first backend and reserve it for the meeting,
+	// so concurrent requests will account for it.
+	backend := backends[0]
+	backend.ReserveRequest(ctx, req)
+	return backend, nil
 }
 
 // LookupBackend will retrieve a backend or will fail
@@ -135,6 +224,55 @@ func (r *Router) LookupBackend(
 	return backend, nil
 }
 
+// LookupParentBackend retrieves the backend of the
+// parent meeting of a breakout room. The parent meeting
+// is identified by its internal meeting ID.
+func (r *Router) LookupParentBackend(
+	ctx context.Context,
+	req *bbb.Request,
+) (*Backend, error) {
+	parentID, ok := req.Params.ParentMeetingID()
+	if !ok {
+		return nil, ErrParentMeetingUnknown
+	}
+	backend, err := GetBackend(ctx, store.Q().
+		Join("meetings ON meetings.backend_id = backends.id").
+		Where(sq.Or{
+			sq.Eq{"meetings.internal_id": parentID},
+			sq.Eq{"meetings.id": parentID},
+		}))
+	if err != nil {
+		return nil, err
+	}
+	if backend == nil {
+		log.Warn().
+			Str("parentMeetingID", parentID).
+			Msg("no backend for parent meeting of breakout room")
+		return nil, ErrParentMeetingUnknown
+	}
+	log.Debug().
+		Str("parentMeetingID", parentID).
+		Str("backend", backend.Host()).
+		Msg("found backend for parent meeting")
+	return backend, nil
+}
+
+// LookupPeakAttendees retrieves the highest number of
+// attendees seen in previous runs of the meeting.
+func LookupPeakAttendees(
+	ctx context.Context,
+	meetingID string,
+) (uint, error) {
+	conn := store.ConnectionFromContext(ctx)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	return store.LookupPeakAttendees(ctx, tx, meetingID)
+}
+
 // LookupBackendForRecordID uses the recordID to identify
 // a backend via the recordings state table.
 func (r *Router) LookupBackendForRecordID(
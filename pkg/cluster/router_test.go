@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestFilterParentAvailable(t *testing.T) {
+	now := time.Now().UTC()
+	ready := &Backend{state: &store.BackendState{
+		NodeState:      "ready",
+		AdminState:     "ready",
+		AgentHeartbeat: now,
+	}}
+	stopped := &Backend{state: &store.BackendState{
+		NodeState:      "ready",
+		AdminState:     "stopped",
+		AgentHeartbeat: now,
+	}}
+	dead := &Backend{state: &store.BackendState{
+		NodeState:      "error",
+		AdminState:     "ready",
+		AgentHeartbeat: now.Add(-time.Hour),
+	}}
+
+	if len(filterParentAvailable([]*Backend{ready})) != 1 {
+		t.Error("ready backend should be available")
+	}
+	if len(filterParentAvailable([]*Backend{stopped})) != 0 {
+		t.Error("stopped backend should not be available")
+	}
+	if len(filterParentAvailable([]*Backend{dead})) != 0 {
+		t.Error("dead backend should not be available")
+	}
+}
+
+func TestFilterCircuitClosed(t *testing.T) {
+	now := time.Now().UTC()
+	open := &Backend{state: &store.BackendState{
+		Circuit: store.CircuitBreaker{
+			State:    store.CircuitOpen,
+			OpenedAt: now,
+		},
+	}}
+	halfOpen := &Backend{state: &store.BackendState{
+		Circuit: store.CircuitBreaker{
+			State:    store.CircuitOpen,
+			OpenedAt: now.Add(-store.CircuitCooldown),
+		},
+	}}
+	closed := &Backend{state: &store.BackendState{}}
+
+	filtered := filterCircuitClosed([]*Backend{open, halfOpen, closed})
+	if len(filtered) != 2 || filtered[0] != halfOpen || filtered[1] != closed {
+		t.Error("unexpected backends:", filtered)
+	}
+
+	// The probe is not claimed while routing is traced
+	ctx := ContextWithRoutingTrace(context.Background(), NewRoutingTrace())
+	if halfOpen.AllowRequest(ctx) {
+		t.Error("probe should not be claimed for a trace")
+	}
+	if !closed.AllowRequest(ctx) {
+		t.Error("closed circuit should allow requests")
+	}
+}
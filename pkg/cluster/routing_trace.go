@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+)
+
+// Routing decisions
+const (
+	RoutingEliminated = "eliminated"
+	RoutingReordered  = "reordered"
+	RoutingKept       = "kept"
+)
+
+// A RoutingDecision is made by a routing middleware
+// about a backend.
+type RoutingDecision struct {
+	Middleware string `json:"middleware" doc:"The routing middleware making the decision." example:"capacity"`
+	BackendID  string `json:"backend_id" doc:"The ID of the backend."`
+	Action     string `json:"action" doc:"The backend was eliminated, moved to another rank or kept although a limit was reached." enum:"eliminated,reordered,kept"`
+	Reason     string `json:"reason" doc:"A description of the decision." example:"attendees limit reached"`
+	Rank       int    `json:"rank,omitempty" doc:"The new rank of a reordered backend, starting at 1."`
+}
+
+// A RoutingTrace records the decisions of the routing
+// middlewares, so the selection of backends can be
+// explained. Tracing is enabled by adding a trace
+// to the context with ContextWithRoutingTrace.
+type RoutingTrace struct {
+	Candidates []*Backend
+	Decisions  []*RoutingDecision
+
+	stress map[string]float64
+	mtx    sync.Mutex
+}
+
+// NewRoutingTrace creates a new empty trace
+func NewRoutingTrace() *RoutingTrace {
+	return &RoutingTrace{
+		Candidates: []*Backend{},
+		Decisions:  []*RoutingDecision{},
+		stress:     map[string]float64{},
+	}
+}
+
+// ContextWithRoutingTrace creates a context with a trace
+func ContextWithRoutingTrace(
+	ctx context.Context, trace *RoutingTrace,
+) context.Context {
+	return context.WithValue(ctx, traceContextKey, trace)
+}
+
+// RoutingTraceFromContext retrieves the trace from a
+// context. If tracing is not enabled, nil is returned.
+func RoutingTraceFromContext(ctx context.Context) *RoutingTrace {
+	trace, ok := ctx.Value(traceContextKey).(*RoutingTrace)
+	if !ok {
+		return nil
+	}
+	return trace
+}
+
+// Stress returns the stress calculated for the backend
+// while routing. If no stress was recorded, ok is false.
+func (t *RoutingTrace) Stress(b *Backend) (stress float64, ok bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	stress, ok = t.stress[b.ID()]
+	return stress, ok
+}
+
+// BackendDecisions returns all decisions about a backend
+func (t *RoutingTrace) BackendDecisions(b *Backend) []*RoutingDecision {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	decisions := []*RoutingDecision{}
+	for _, d := range t.Decisions {
+		if d.BackendID == b.ID() {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions
+}
+
+// EliminatedBy returns the middleware which removed the
+// backend. If the backend was not removed, the result
+// is empty.
+func (t *RoutingTrace) EliminatedBy(b *Backend) string {
+	for _, d := range t.BackendDecisions(b) {
+		if d.Action == RoutingEliminated {
+			return d.Middleware
+		}
+	}
+	return ""
+}
+
+// add appends a decision to the trace
+func (t *RoutingTrace) add(d *RoutingDecision) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.Decisions = append(t.Decisions, d)
+}
+
+// TraceCandidates records the backends considered
+// for routing the request.
+func TraceCandidates(ctx context.Context, backends []*Backend) {
+	trace := RoutingTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	trace.mtx.Lock()
+	defer trace.mtx.Unlock()
+	trace.Candidates = append(trace.Candidates, backends...)
+}
+
+// TraceFilter records all backends which were removed
+// by a routing middleware.
+func TraceFilter(
+	ctx context.Context,
+	middleware string,
+	before []*Backend,
+	after []*Backend,
+	reason string,
+) {
+	trace := RoutingTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	kept := make(map[*Backend]bool, len(after))
+	for _, be := range after {
+		kept[be] = true
+	}
+	for _, be := range before {
+		if kept[be] {
+			continue
+		}
+		trace.add(&RoutingDecision{
+			Middleware: middleware,
+			BackendID:  be.ID(),
+			Action:     RoutingEliminated,
+			Reason:     reason,
+		})
+	}
+}
+
+// TraceKeep records backends which were not removed
+// by a routing middleware, because no backend would
+// be left otherwise.
+func TraceKeep(
+	ctx context.Context,
+	middleware string,
+	backends []*Backend,
+	reason string,
+) {
+	trace := RoutingTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	for _, be := range backends {
+		trace.add(&RoutingDecision{
+			Middleware: middleware,
+			BackendID:  be.ID(),
+			Action:     RoutingKept,
+			Reason:     reason,
+		})
+	}
+}
+
+// TraceOrder records all backends which were moved to
+// another rank by a routing middleware.
+func TraceOrder(
+	ctx context.Context,
+	middleware string,
+	before []*Backend,
+	after []*Backend,
+	reason string,
+) {
+	trace := RoutingTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	for i, be := range after {
+		if i < len(before) && before[i] == be {
+			continue
+		}
+		trace.add(&RoutingDecision{
+			Middleware: middleware,
+			BackendID:  be.ID(),
+			Action:     RoutingReordered,
+			Reason:     reason,
+			Rank:       i + 1,
+		})
+	}
+}
+
+// TraceStress records the stress of a backend
+// calculated by a routing middleware.
+func TraceStress(ctx context.Context, b *Backend, stress float64) {
+	trace := RoutingTraceFromContext(ctx)
+	if trace == nil {
+		return
+	}
+	trace.mtx.Lock()
+	defer trace.mtx.Unlock()
+	trace.stress[b.ID()] = stress
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestRoutingTrace(t *testing.T) {
+	b1 := NewBackend(&store.BackendState{ID: "b1"})
+	b2 := NewBackend(&store.BackendState{ID: "b2"})
+	b3 := NewBackend(&store.BackendState{ID: "b3"})
+
+	// Tracing is disabled without a trace in the context
+	ctx := context.Background()
+	TraceFilter(ctx, "filter", []*Backend{b1, b2}, []*Backend{b1}, "")
+
+	trace := NewRoutingTrace()
+	ctx = ContextWithRoutingTrace(ctx, trace)
+	TraceCandidates(ctx, []*Backend{b1, b2, b3})
+	TraceFilter(ctx, "filter", []*Backend{b1, b2, b3}, []*Backend{b1, b3}, "test")
+	TraceOrder(ctx, "sort", []*Backend{b1, b3}, []*Backend{b3, b1}, "test")
+	TraceStress(ctx, b3, 42)
+	TraceKeep(ctx, "limit", []*Backend{b3}, "test")
+
+	if len(trace.Candidates) != 3 {
+		t.Error("unexpected candidates:", trace.Candidates)
+	}
+	if by := trace.EliminatedBy(b2); by != "filter" {
+		t.Error("unexpected eliminated by:", by)
+	}
+	if by := trace.EliminatedBy(b1); by != "" {
+		t.Error("unexpected eliminated by:", by)
+	}
+
+	decisions := trace.BackendDecisions(b1)
+	if len(decisions) != 1 {
+		t.Fatal("unexpected decisions:", decisions)
+	}
+	if decisions[0].Action != RoutingReordered || decisions[0].Rank != 2 {
+		t.Error("unexpected decision:", decisions[0])
+	}
+
+	decisions = trace.BackendDecisions(b3)
+	if len(decisions) != 2 || decisions[1].Action != RoutingKept {
+		t.Error("unexpected decisions:", decisions)
+	}
+	if by := trace.EliminatedBy(b3); by != "" {
+		t.Error("unexpected eliminated by:", by)
+	}
+
+	if stress, ok := trace.Stress(b3); !ok || stress != 42 {
+		t.Error("unexpected stress:", stress)
+	}
+	if _, ok := trace.Stress(b1); ok {
+		t.Error("did not expect stress for b1")
+	}
+}
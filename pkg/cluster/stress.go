@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Well known stress strategies
+const (
+	StressStrategyDefault       = "default"
+	StressStrategyAttendees     = "attendees"
+	StressStrategyLatency       = "latency"
+	StressStrategyLeastMeetings = "meetings"
+	StressStrategyPacking       = "packing"
+)
+
+// AttendeeBaseLoad is the number of attendees assumed
+// for each backend, even if it is idle. This prevents
+// the scoring from piling meetings onto a node with
+// few attendees.
+const AttendeeBaseLoad = 15.0
+
+// A StressStrategy calculates a score for a backend.
+// Backends with a lower stress are preferred when
+// a new meeting is created.
+type StressStrategy interface {
+	Stress(b *Backend) float64
+}
+
+// StressFunc is an adapter to allow using a function
+// as a StressStrategy.
+type StressFunc func(b *Backend) float64
+
+// Stress calls the wrapped function
+func (f StressFunc) Stress(b *Backend) float64 {
+	return f(b)
+}
+
+var (
+	stressStrategies     = map[string]StressStrategy{}
+	stressStrategiesLock sync.RWMutex
+)
+
+// RegisterStressStrategy makes a stress strategy available
+// by name. An existing strategy with the same name
+// will be replaced.
+func RegisterStressStrategy(name string, strategy StressStrategy) {
+	stressStrategiesLock.Lock()
+	defer stressStrategiesLock.Unlock()
+	stressStrategies[name] = strategy
+}
+
+// GetStressStrategy retrieves a registered strategy by name.
+// If the strategy is unknown, nil is returned.
+func GetStressStrategy(name string) StressStrategy {
+	stressStrategiesLock.RLock()
+	defer stressStrategiesLock.RUnlock()
+	return stressStrategies[name]
+}
+
+// StressStrategyNames returns a sorted list of all
+// registered strategies.
+func StressStrategyNames() []string {
+	stressStrategiesLock.RLock()
+	defer stressStrategiesLock.RUnlock()
+	names := make([]string, 0, len(stressStrategies))
+	for name := range stressStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// meetingsLoad is the number of meetings on the backend
+// including meetings which are about to be created.
+func meetingsLoad(b *Backend) float64 {
+	return float64(b.state.MeetingsCount + b.state.ReservationsCount)
+}
+
+// DefaultStress weights the number of meetings and
+// attendees, assuming a base load of attendees.
+func DefaultStress(b *Backend) float64 {
+	f := b.state.LoadFactor
+	attendeeLoad := math.Max(AttendeeBaseLoad, float64(b.state.AttendeesCount))
+	return f * (meetingsLoad(b) + attendeeLoad)
+}
+
+// AttendeesStress only considers the number of attendees.
+// This is well suited for many small meetings.
+// A reserved meeting is accounted for with one attendee.
+func AttendeesStress(b *Backend) float64 {
+	f := b.state.LoadFactor
+	return f * float64(b.state.AttendeesCount+b.state.ReservationsCount)
+}
+
+// LatencyStress scales the default stress with the
+// median latency of the backend in seconds.
+func LatencyStress(b *Backend) float64 {
+	return DefaultStress(b) * (1.0 + b.Latency().Seconds())
+}
+
+// LeastMeetingsStress only considers the number of meetings.
+// This is well suited for few large meetings.
+func LeastMeetingsStress(b *Backend) float64 {
+	f := b.state.LoadFactor
+	return f * meetingsLoad(b)
+}
+
+// PackingStress prefers the backend with the most load.
+// Meetings are packed onto as few backends as possible, so
+// idle backends can be shut down. This requires capacity
+// limits in the backend settings, otherwise all meetings
+// will be created on the same backend.
+//
+// The load is divided by the load factor, so a backend with
+// a higher load factor is still less preferred.
+func PackingStress(b *Backend) float64 {
+	f := b.state.LoadFactor
+	if f <= 0 {
+		f = 1.0
+	}
+	attendeeLoad := math.Max(AttendeeBaseLoad, float64(b.state.AttendeesCount))
+	return -(meetingsLoad(b) + attendeeLoad) / f
+}
+
+func init() {
+	RegisterStressStrategy(
+		StressStrategyDefault, StressFunc(DefaultStress))
+	RegisterStressStrategy(
+		StressStrategyAttendees, StressFunc(AttendeesStress))
+	RegisterStressStrategy(
+		StressStrategyLatency, StressFunc(LatencyStress))
+	RegisterStressStrategy(
+		StressStrategyLeastMeetings, StressFunc(LeastMeetingsStress))
+	RegisterStressStrategy(
+		StressStrategyPacking, StressFunc(PackingStress))
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// stressWindow is a daily time window in which
+// a stress strategy is used.
+type stressWindow struct {
+	from     time.Duration
+	to       time.Duration
+	strategy StressStrategy
+}
+
+// contains checks if the time of day is within the
+// window. Windows may span midnight.
+func (w *stressWindow) contains(t time.Duration) bool {
+	if w.from <= w.to {
+		return t >= w.from && t < w.to
+	}
+	return t >= w.from || t < w.to
+}
+
+// A StressSchedule selects a stress strategy
+// depending on the time of day. If no window matches,
+// the fallback strategy is used.
+type StressSchedule struct {
+	windows  []*stressWindow
+	fallback StressStrategy
+}
+
+// ParseStressSchedule creates a schedule from a list of
+// comma separated time windows with a strategy, e.g.
+//
+//   22:00-06:00=packing,12:00-13:00=meetings
+//
+// The time is the local time of the server.
+func ParseStressSchedule(
+	spec string,
+	fallback StressStrategy,
+) (*StressSchedule, error) {
+	schedule := &StressSchedule{
+		windows:  []*stressWindow{},
+		fallback: fallback,
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tokens := strings.SplitN(entry, "=", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("invalid schedule entry: %s", entry)
+		}
+		span := strings.SplitN(tokens[0], "-", 2)
+		if len(span) != 2 {
+			return nil, fmt.Errorf("invalid time window: %s", tokens[0])
+		}
+		from, err := parseTimeOfDay(span[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseTimeOfDay(span[1])
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(tokens[1])
+		strategy := GetStressStrategy(name)
+		if strategy == nil {
+			return nil, fmt.Errorf("unknown stress strategy: %s", name)
+		}
+		schedule.windows = append(schedule.windows, &stressWindow{
+			from:     from,
+			to:       to,
+			strategy: strategy,
+		})
+	}
+	return schedule, nil
+}
+
+// parseTimeOfDay parses a HH:MM time into the
+// duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute, nil
+}
+
+// StrategyAt selects the stress strategy for a
+// point in time.
+func (s *StressSchedule) StrategyAt(t time.Time) StressStrategy {
+	y, m, d := t.Date()
+	tod := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	for _, w := range s.windows {
+		if w.contains(tod) {
+			return w.strategy
+		}
+	}
+	return s.fallback
+}
+
+// Stress calculates the backend stress with the
+// strategy selected for the current time.
+func (s *StressSchedule) Stress(b *Backend) float64 {
+	return s.StrategyAt(time.Now()).Stress(b)
+}
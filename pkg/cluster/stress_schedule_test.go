@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestParseStressSchedule(t *testing.T) {
+	fallback := GetStressStrategy(StressStrategyDefault)
+	schedule, err := ParseStressSchedule(
+		"22:00-06:00=packing, 12:00-13:00=meetings", fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(h, m int) time.Time {
+		return time.Date(2022, 11, 3, h, m, 0, 0, time.Local)
+	}
+	b := &Backend{state: &store.BackendState{
+		MeetingsCount:  10,
+		LoadFactor:     1,
+		AttendeesCount: 20,
+	}}
+
+	tests := []struct {
+		t        time.Time
+		expected float64
+	}{
+		{at(23, 0), PackingStress(b)},
+		{at(2, 30), PackingStress(b)},
+		{at(6, 0), DefaultStress(b)},
+		{at(12, 15), LeastMeetingsStress(b)},
+		{at(13, 0), DefaultStress(b)},
+	}
+	for _, test := range tests {
+		s := schedule.StrategyAt(test.t).Stress(b)
+		if s != test.expected {
+			t.Error("unexpected stress at", test.t, ":", s)
+		}
+	}
+}
+
+func TestParseStressScheduleErrors(t *testing.T) {
+	specs := []string{
+		"22:00-06:00",
+		"22:00=packing",
+		"25:00-06:00=packing",
+		"22:00-06:00=unknown",
+	}
+	for _, spec := range specs {
+		if _, err := ParseStressSchedule(spec, nil); err == nil {
+			t.Error("expected error for:", spec)
+		}
+	}
+}
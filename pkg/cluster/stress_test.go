@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestStressStrategies(t *testing.T) {
+	b := &Backend{state: &store.BackendState{
+		ID:             "A",
+		MeetingsCount:  10,
+		LoadFactor:     2,
+		AttendeesCount: 12,
+		Latency:        500 * time.Millisecond,
+	}}
+
+	tests := map[string]float64{
+		StressStrategyDefault:       50,
+		StressStrategyAttendees:     24,
+		StressStrategyLatency:       75,
+		StressStrategyLeastMeetings: 20,
+	}
+	for name, expected := range tests {
+		strategy := GetStressStrategy(name)
+		if strategy == nil {
+			t.Fatal("strategy not registered:", name)
+		}
+		if s := strategy.Stress(b); s != expected {
+			t.Error("unexpected stress for", name, ":", s)
+		}
+	}
+}
+
+func TestGetStressStrategyUnknown(t *testing.T) {
+	if GetStressStrategy("unknown") != nil {
+		t.Error("expected nil for unknown strategy")
+	}
+}
+
+func TestRegisterStressStrategy(t *testing.T) {
+	RegisterStressStrategy("constant", StressFunc(func(*Backend) float64 {
+		return 42
+	}))
+	s := GetStressStrategy("constant")
+	if s == nil {
+		t.Fatal("strategy was not registered")
+	}
+	if s.Stress(&Backend{}) != 42 {
+		t.Error("unexpected stress:", s.Stress(&Backend{}))
+	}
+}
+
+func TestStressReservations(t *testing.T) {
+	b := &Backend{state: &store.BackendState{
+		ID:                "A",
+		MeetingsCount:     10,
+		LoadFactor:        1,
+		AttendeesCount:    20,
+		ReservationsCount: 2,
+	}}
+	if s := DefaultStress(b); s != 32 {
+		t.Error("unexpected stress:", s)
+	}
+	if s := AttendeesStress(b); s != 22 {
+		t.Error("unexpected stress:", s)
+	}
+	if s := LeastMeetingsStress(b); s != 12 {
+		t.Error("unexpected stress:", s)
+	}
+}
+
+func TestPackingStress(t *testing.T) {
+	idle := &Backend{state: &store.BackendState{
+		LoadFactor: 1,
+	}}
+	busy := &Backend{state: &store.BackendState{
+		MeetingsCount:  10,
+		LoadFactor:     1,
+		AttendeesCount: 100,
+	}}
+	if PackingStress(busy) >= PackingStress(idle) {
+		t.Error("busy backend should be preferred")
+	}
+
+	// A higher load factor makes a backend less preferred
+	weighted := &Backend{state: &store.BackendState{
+		MeetingsCount:  10,
+		LoadFactor:     2,
+		AttendeesCount: 100,
+	}}
+	if PackingStress(weighted) <= PackingStress(busy) {
+		t.Error("backend with higher load factor should be less preferred")
+	}
+}
@@ -91,6 +91,8 @@ func decodeEvent(msg *redis.Message) bbb.Event {
 		return safeDecode(decodeUserJoinedMeetingEvent, m)
 	case "UserLeftMeetingEvtMsg":
 		return safeDecode(decodeUserLeftMeetingEvent, m)
+	case "BreakoutRoomStartedEvtMsg":
+		return safeDecode(decodeBreakoutRoomStartedEvent, m)
 	}
 
 	return nil
@@ -151,3 +153,23 @@ func decodeUserLeftMeetingEvent(m *Message) bbb.Event {
 		InternalUserID:    header["userId"].(string),
 	}
 }
+
+func decodeBreakoutRoomStartedEvent(m *Message) bbb.Event {
+	body := m.Core.Body
+	breakout := body["breakout"].(map[string]interface{})
+	info := &bbb.BreakoutInfo{
+		Name:       breakout["name"].(string),
+		ExternalID: breakout["externalId"].(string),
+		BreakoutID: breakout["breakoutId"].(string),
+	}
+	if seq, ok := breakout["sequence"].(float64); ok {
+		info.Sequence = int(seq)
+	}
+	if freeJoin, ok := breakout["freeJoin"].(bool); ok {
+		info.FreeJoin = freeJoin
+	}
+	return &bbb.BreakoutRoomStartedEvent{
+		ParentInternalMeetingID: body["parentMeetingId"].(string),
+		Breakout:                info,
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog/log"
 
+	"github.com/b3scale/b3scale/pkg/cluster"
 	"github.com/b3scale/b3scale/pkg/config"
 	"github.com/b3scale/b3scale/pkg/store"
 	"github.com/b3scale/b3scale/pkg/store/schema"
@@ -50,6 +51,8 @@ type API struct {
 	Ref    string
 	// Database
 	Conn *pgxpool.Conn
+	// Cluster
+	Router *cluster.Router
 
 	echo.Context
 }
@@ -115,9 +118,50 @@ func ContextMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// RouterMiddleware adds the cluster router to
+// the API context.
+func RouterMiddleware(router *cluster.Router) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			api := c.(*API)
+			api.Router = router
+			return next(api)
+		}
+	}
+}
+
+// leaderLease retrieves the lease of the controller
+// instance scheduling the background tasks.
+func leaderLease(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+) (*store.LeaderLease, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	return store.GetLeaderLease(ctx, tx, cluster.LeaderLeaseController)
+}
+
+// instanceStates retrieves the states of the
+// instances serving the cluster.
+func instanceStates(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+) ([]*store.InstanceState, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	return store.GetInstanceStates(ctx, tx, store.Q().
+		OrderBy("started_at ASC"))
+}
+
 // Init sets up a group with authentication
 // for a restful management interface.
-func Init(e *echo.Echo) error {
+func Init(e *echo.Echo, router *cluster.Router) error {
 	// Initialize JWT middleware config
 	jwtConfig, err := NewAPIJWTConfig()
 	if err != nil {
@@ -132,6 +176,7 @@ func Init(e *echo.Echo) error {
 	v1.Use(middleware.JWTWithConfig(jwtConfig))
 	v1.Use(ErrorHandler)
 	v1.Use(ContextMiddleware)
+	v1.Use(RouterMiddleware(router))
 
 	// Status
 	v1.GET("", Endpoint(apiStatusShow))
@@ -140,6 +185,7 @@ func Init(e *echo.Echo) error {
 	// API resources
 	ResourceFrontends.Mount(v1, "/frontends")
 	ResourceBackends.Mount(v1, "/backends")
+	ResourceMaintenanceWindows.Mount(v1, "/maintenance-windows")
 	ResourceMeetings.Mount(v1, "/meetings")
 	ResourceCommands.Mount(v1, "/commands")
 	ResourceRecordingsImport.Mount(v1, "/recordings-import")
@@ -147,6 +193,7 @@ func Init(e *echo.Echo) error {
 	ResourceAgentBackend.Mount(v1, "/agent/backend")
 	ResourceAgentHeartbeat.Mount(v1, "/agent/heartbeat")
 	ResourceCtlMigrate.Mount(v1, "/ctrl/migrate")
+	ResourceRoutingDryRun.Mount(v1, "/routing/dry-run")
 	return nil
 }
 
@@ -159,6 +206,9 @@ type StatusResponse struct {
 	AccountRef string         `json:"account_ref" doc:"The currently authenticated subject."`
 	IsAdmin    bool           `json:"is_admin" doc:"True if the subject has admin privileges."`
 	Database   *schema.Status `json:"database" doc:"Status of the database" api:"SchemaStatus"`
+
+	Leader    *store.LeaderLease     `json:"leader" doc:"The instance currently scheduling the background tasks. This is null if no leader was elected yet." api:"LeaderLease"`
+	Instances []*store.InstanceState `json:"instances,omitempty" doc:"The b3scaled instances serving the cluster. Only present for admins."`
 }
 
 // apiStatusShow will respond with the api version and b3scale
@@ -174,5 +224,22 @@ func apiStatusShow(ctx context.Context, api *API) error {
 		IsAdmin:    api.HasScope(ScopeAdmin),
 		Database:   m.Status(ctx),
 	}
+
+	// The lease table might not be present if the database
+	// is not migrated, so we only report the leader if known.
+	leader, err := leaderLease(ctx, api.Conn)
+	if err != nil {
+		log.Debug().Err(err).Msg("could not get leader lease")
+	}
+	status.Leader = leader
+
+	if status.IsAdmin {
+		instances, err := instanceStates(ctx, api.Conn)
+		if err != nil {
+			log.Debug().Err(err).Msg("could not get instances")
+		}
+		status.Instances = instances
+	}
+
 	return api.JSON(http.StatusOK, status)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -68,6 +69,18 @@ func apiBackendsList(
 		q = q.Where("host ILIKE ?", fmt.Sprintf("%%%s%%", queryHostILike))
 	}
 
+	// Filter idle backends without meetings
+	if config.IsEnabled(api.QueryParam("idle")) {
+		q = q.Where(`NOT EXISTS (
+			SELECT 1 FROM meetings
+			 WHERE meetings.backend_id = backends.id)`).
+			Where(`NOT EXISTS (
+			SELECT 1 FROM backend_reservations
+			 WHERE backend_reservations.backend_id = backends.id
+			   AND backend_reservations.created_at >= ?)`,
+				time.Now().UTC().Add(-store.ReservationTTL))
+	}
+
 	// Set ordering
 	q = q.OrderBy("backends.host ASC")
 
@@ -56,6 +56,26 @@ type BackendResourceClient interface {
 	) (*store.BackendState, error)
 }
 
+// MaintenanceWindowResourceClient defines methods for
+// scheduling backend maintenance windows
+type MaintenanceWindowResourceClient interface {
+	MaintenanceWindowsList(
+		ctx context.Context, query ...url.Values,
+	) ([]*store.MaintenanceWindow, error)
+	MaintenanceWindowRetrieve(
+		ctx context.Context, id string,
+	) (*store.MaintenanceWindow, error)
+	MaintenanceWindowCreate(
+		ctx context.Context, window *store.MaintenanceWindow,
+	) (*store.MaintenanceWindow, error)
+	MaintenanceWindowUpdate(
+		ctx context.Context, window *store.MaintenanceWindow,
+	) (*store.MaintenanceWindow, error)
+	MaintenanceWindowDelete(
+		ctx context.Context, window *store.MaintenanceWindow,
+	) (*store.MaintenanceWindow, error)
+}
+
 // MeetingResourceClient defines methods for accessing
 // the meetings api resource
 type MeetingResourceClient interface {
@@ -95,6 +115,10 @@ type CommandResourceClient interface {
 		ctx context.Context,
 		backendID string,
 	) (*store.Command, error)
+	FrontendMeetingsEnd(
+		ctx context.Context,
+		frontendID string,
+	) (*store.Command, error)
 
 	CommandCreate(
 		ctx context.Context,
@@ -104,6 +128,14 @@ type CommandResourceClient interface {
 		ctx context.Context,
 		id string,
 	) (*store.Command, error)
+	CommandsList(
+		ctx context.Context,
+		query ...url.Values,
+	) ([]*store.Command, error)
+	CommandRequeue(
+		ctx context.Context,
+		id string,
+	) (*store.Command, error)
 
 	// Control commands
 	CtrlMigrate(
@@ -126,13 +158,24 @@ type AgentResourceClient interface {
 	) (RPCResult, error)
 }
 
+// RoutingResourceClient defines methods for
+// evaluating the routing of the cluster
+type RoutingResourceClient interface {
+	RoutingDryRun(
+		ctx context.Context,
+		req *RoutingDryRunRequest,
+	) (*RoutingDryRunResult, error)
+}
+
 // Client is an interface to the api API.
 type Client interface {
 	Status(ctx context.Context) (*StatusResponse, error)
 
 	FrontendResourceClient
 	BackendResourceClient
+	MaintenanceWindowResourceClient
 	MeetingResourceClient
 	CommandResourceClient
 	AgentResourceClient
+	RoutingResourceClient
 }
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"net/url"
 
 	"github.com/b3scale/b3scale/pkg/cluster"
 	"github.com/b3scale/b3scale/pkg/store"
@@ -53,6 +54,46 @@ func (c *Client) CommandRetrieve(
 	return cmd, nil
 }
 
+// CommandsList retrieves the command queue. The
+// query can filter by state and action.
+func (c *Client) CommandsList(
+	ctx context.Context,
+	query ...url.Values,
+) ([]*store.Command, error) {
+	res, err := c.Request(ctx, Fetch(Commands(), query...))
+	if err != nil {
+		return nil, err
+	}
+	cmds := []*store.Command{}
+	if err := res.JSON(&cmds); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+// CommandRequeue runs a failed command again
+// with all attempts.
+func (c *Client) CommandRequeue(
+	ctx context.Context,
+	id string,
+) (*store.Command, error) {
+	payload, err := json.Marshal(map[string]string{
+		"state": store.CommandRequested,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Request(ctx, Update(Commands(id), payload))
+	if err != nil {
+		return nil, err
+	}
+	cmd := &store.Command{}
+	if err := res.JSON(cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
 // BackendMeetingsEnd ends all meetings on a given backend
 func (c *Client) BackendMeetingsEnd(
 	ctx context.Context,
@@ -64,6 +105,18 @@ func (c *Client) BackendMeetingsEnd(
 	return c.CommandCreate(ctx, cmd)
 }
 
+// FrontendMeetingsEnd is a shortcut to end all meetings
+// of a frontend on all backends.
+func (c *Client) FrontendMeetingsEnd(
+	ctx context.Context,
+	frontendID string,
+) (*store.Command, error) {
+	cmd := cluster.EndFrontendMeetings(&cluster.EndFrontendMeetingsRequest{
+		FrontendID: frontendID,
+	})
+	return c.CommandCreate(ctx, cmd)
+}
+
 // CtrlMigrate applies all pending migrations
 func (c *Client) CtrlMigrate(ctx context.Context) (*schema.Status, error) {
 	res, err := c.Request(ctx, Create(Resource("ctrl/migrate", nil), nil))
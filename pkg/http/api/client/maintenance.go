@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// MaintenanceWindows creates a maintenance windows
+// resource string
+func MaintenanceWindows(id ...string) string {
+	return Resource("maintenance-windows", id)
+}
+
+// MaintenanceWindowsList retrieves a list of
+// maintenance windows from the server
+func (c *Client) MaintenanceWindowsList(
+	ctx context.Context,
+	query ...url.Values,
+) ([]*store.MaintenanceWindow, error) {
+	res, err := c.Request(ctx, Fetch(MaintenanceWindows(), query...))
+	if err != nil {
+		return nil, err
+	}
+	windows := []*store.MaintenanceWindow{}
+	if err := res.JSON(&windows); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// MaintenanceWindowRetrieve retrieves a single
+// maintenance window by ID.
+func (c *Client) MaintenanceWindowRetrieve(
+	ctx context.Context,
+	id string,
+) (*store.MaintenanceWindow, error) {
+	res, err := c.Request(ctx, Fetch(MaintenanceWindows(id)))
+	if err != nil {
+		return nil, err
+	}
+	window := &store.MaintenanceWindow{}
+	if err := res.JSON(window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// MaintenanceWindowCreate schedules a new maintenance
+// window on the server
+func (c *Client) MaintenanceWindowCreate(
+	ctx context.Context,
+	window *store.MaintenanceWindow,
+) (*store.MaintenanceWindow, error) {
+	payload, err := json.Marshal(window)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Request(ctx, Create(MaintenanceWindows(), payload))
+	if err != nil {
+		return nil, err
+	}
+	window = &store.MaintenanceWindow{}
+	if err := res.JSON(window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// MaintenanceWindowUpdate updates the mode and the
+// times of a maintenance window
+func (c *Client) MaintenanceWindowUpdate(
+	ctx context.Context,
+	window *store.MaintenanceWindow,
+) (*store.MaintenanceWindow, error) {
+	payload, err := json.Marshal(window)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Request(ctx, Update(MaintenanceWindows(window.ID), payload))
+	if err != nil {
+		return nil, err
+	}
+	window = &store.MaintenanceWindow{}
+	if err := res.JSON(window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// MaintenanceWindowDelete removes a maintenance window,
+// or ends it when it is active
+func (c *Client) MaintenanceWindowDelete(
+	ctx context.Context,
+	window *store.MaintenanceWindow,
+) (*store.MaintenanceWindow, error) {
+	res, err := c.Request(ctx, Destroy(MaintenanceWindows(window.ID)))
+	if err != nil {
+		return nil, err
+	}
+	window = &store.MaintenanceWindow{}
+	if err := res.JSON(window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
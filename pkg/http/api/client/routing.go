@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/b3scale/b3scale/pkg/http/api"
+)
+
+// RoutingDryRun evaluates the routing of a hypothetical
+// create request and retrieves the decisions made about
+// each backend.
+func (c *Client) RoutingDryRun(
+	ctx context.Context,
+	req *api.RoutingDryRunRequest,
+) (*api.RoutingDryRunResult, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Request(ctx, Create("routing/dry-run", payload))
+	if err != nil {
+		return nil, err
+	}
+	result := &api.RoutingDryRunResult{}
+	if err := res.JSON(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/b3scale/b3scale/pkg/cluster"
@@ -22,6 +23,10 @@ var ResourceCommands = &Resource{
 	Create: RequireScope(
 		ScopeAdmin,
 	)(apiCommandCreate),
+
+	Update: RequireScope(
+		ScopeAdmin,
+	)(apiCommandUpdate),
 }
 
 // ErrCommandNotAllowed is a validation error
@@ -29,12 +34,60 @@ var ErrCommandNotAllowed = store.ValidationError{
 	"action": []string{"this action is not allowed"},
 }
 
+// A commandCreateRequest is a command created through
+// the API. The priority is a pointer, so an explicit
+// priority of 0 can be told apart from a missing priority.
+type commandCreateRequest struct {
+	Action      string      `json:"action"`
+	Params      interface{} `json:"params"`
+	MaxAttempts int         `json:"max_attempts"`
+	Priority    *int        `json:"priority"`
+}
+
+// Command creates the command for the queue. Missing
+// max attempts and priority are set to the defaults
+// for commands created through the API.
+func (r *commandCreateRequest) Command() *store.Command {
+	cmd := &store.Command{
+		Action:      r.Action,
+		Params:      r.Params,
+		MaxAttempts: r.MaxAttempts,
+		Priority:    store.CommandPriorityHigh,
+	}
+	if cmd.MaxAttempts == 0 {
+		cmd.MaxAttempts = store.DefaultCommandMaxAttempts
+	}
+	if r.Priority != nil {
+		cmd.Priority = *r.Priority
+	}
+	return cmd
+}
+
 // validateCommand checks if the command is ok
 func validateCommand(cmd *store.Command) error {
-	// Only allow DeleteBackend for now
-	if cmd.Action != cluster.CmdEndAllMeetings {
+	switch cmd.Action {
+	case cluster.CmdEndAllMeetings:
+	case cluster.CmdEndFrontendMeetings:
+	default:
 		return ErrCommandNotAllowed
 	}
+
+	// A max attempts of 0 selects the default.
+	err := store.ValidationError{}
+	if cmd.MaxAttempts < 0 || cmd.MaxAttempts > store.CommandMaxAttemptsLimit {
+		err.Add("max_attempts", fmt.Sprintf(
+			"must be between 1 and %d, or 0 for the default of %d",
+			store.CommandMaxAttemptsLimit, store.DefaultCommandMaxAttempts))
+	}
+	if cmd.Priority < store.CommandPriorityLow ||
+		cmd.Priority > store.CommandPriorityHigh {
+		err.Add("priority", fmt.Sprintf(
+			"must be between %d and %d",
+			store.CommandPriorityLow, store.CommandPriorityHigh))
+	}
+	if len(err) > 0 {
+		return err
+	}
 	return nil
 }
 
@@ -47,7 +100,20 @@ func apiCommandList(ctx context.Context, api *API) error {
 	}
 	defer tx.Rollback(ctx)
 
-	commands, err := store.GetCommands(ctx, tx, store.Q())
+	q := store.Q()
+
+	// Query parameter filters
+	queryState := api.QueryParam("state")
+	if queryState != "" {
+		q = q.Where("state = ?", queryState)
+	}
+	queryAction := api.QueryParam("action")
+	if queryAction != "" {
+		q = q.Where("action = ?", queryAction)
+	}
+	q = q.OrderBy("seq ASC")
+
+	commands, err := store.GetCommands(ctx, tx, q)
 	if err != nil {
 		return err
 	}
@@ -84,10 +150,11 @@ func apiCommandCreate(ctx context.Context, api *API) error {
 	defer tx.Rollback(ctx)
 
 	// Parse command and insert into queue
-	cmd := &store.Command{}
-	if err := api.Bind(cmd); err != nil {
+	req := &commandCreateRequest{}
+	if err := api.Bind(req); err != nil {
 		return err
 	}
+	cmd := req.Command()
 	if err := validateCommand(cmd); err != nil {
 		return err
 	}
@@ -101,3 +168,42 @@ func apiCommandCreate(ctx context.Context, api *API) error {
 	// Ok
 	return api.JSON(http.StatusAccepted, cmd)
 }
+
+// apiCommandUpdate requeues a failed command. The only
+// allowed update is setting the state to requested.
+func apiCommandUpdate(ctx context.Context, api *API) error {
+	update := &store.Command{}
+	if err := api.Bind(update); err != nil {
+		return err
+	}
+	if update.State != store.CommandRequested {
+		return store.ValidationError{
+			"state": []string{"a command can only be requeued"},
+		}
+	}
+
+	// Begin TX
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	id := api.Param("id")
+	if err := store.RequeueCommand(ctx, tx, id); err != nil {
+		if err == store.ErrCommandNotFailed {
+			return store.ValidationError{
+				"state": []string{err.Error()},
+			}
+		}
+		return err
+	}
+	cmd, err := store.GetCommand(ctx, tx, store.Q().Where("id = ?", id))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return api.JSON(http.StatusOK, cmd)
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
 )
 
 func TestQueueBackendMeetingsEnd(t *testing.T) {
@@ -25,3 +27,64 @@ func TestQueueBackendMeetingsEnd(t *testing.T) {
 	}
 	t.Log(res.Body())
 }
+
+func TestValidateCommand(t *testing.T) {
+	cmd := cluster.EndAllMeetings(&cluster.EndAllMeetingsRequest{
+		BackendID: "some-backend-id",
+	})
+	if err := validateCommand(cmd); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	// 0 selects the default
+	cmd.MaxAttempts = 0
+	if err := validateCommand(cmd); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	cmd.MaxAttempts = -1
+	if err := validateCommand(cmd); err == nil {
+		t.Error("expected an error for max_attempts")
+	}
+
+	cmd.MaxAttempts = store.CommandMaxAttemptsLimit + 1
+	cmd.Priority = store.CommandPriorityHigh + 1
+	err := validateCommand(cmd)
+	verr, ok := err.(store.ValidationError)
+	if !ok {
+		t.Fatal("expected a validation error, got:", err)
+	}
+	if verr["max_attempts"] == nil || verr["priority"] == nil {
+		t.Error("unexpected errors:", verr)
+	}
+
+	cmd.Action = cluster.CmdCollectGarbage
+	if err := validateCommand(cmd); err == nil {
+		t.Error("expected an error for the action")
+	}
+}
+
+func TestCommandCreateRequestCommand(t *testing.T) {
+	req := &commandCreateRequest{}
+	if err := json.Unmarshal(
+		[]byte(`{"action": "end_all_meetings"}`), req); err != nil {
+		t.Fatal(err)
+	}
+	cmd := req.Command()
+	if cmd.Priority != store.CommandPriorityHigh {
+		t.Error("unexpected priority:", cmd.Priority)
+	}
+	if cmd.MaxAttempts != store.DefaultCommandMaxAttempts {
+		t.Error("unexpected max attempts:", cmd.MaxAttempts)
+	}
+
+	// An explicit priority of 0 is kept
+	req = &commandCreateRequest{}
+	if err := json.Unmarshal(
+		[]byte(`{"action": "end_all_meetings", "priority": 0}`), req); err != nil {
+		t.Fatal(err)
+	}
+	cmd = req.Command()
+	if cmd.Priority != store.CommandPriorityNormal {
+		t.Error("unexpected priority:", cmd.Priority)
+	}
+}
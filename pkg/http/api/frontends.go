@@ -7,6 +7,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/b3scale/b3scale/pkg/cluster"
 	"github.com/b3scale/b3scale/pkg/store"
 )
 
@@ -90,7 +91,7 @@ func apiFrontendCreate(
 		AccountRef: f.AccountRef,
 	})
 
-	if err := frontend.Validate(); err != nil {
+	if err := frontend.Validate(cluster.StressStrategyNames()); err != nil {
 		return err
 	}
 
@@ -258,7 +259,7 @@ func apiFrontendUpdate(
 		frontend.AccountRef = update.AccountRef
 	}
 
-	if err := frontend.Validate(); err != nil {
+	if err := frontend.Validate(cluster.StressStrategyNames()); err != nil {
 		return err
 	}
 	if err := frontend.Save(ctx, tx); err != nil {
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/labstack/echo/v4"
+
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// ResourceMaintenanceWindows is a restful group for
+// scheduling backend maintenance windows
+var ResourceMaintenanceWindows = &Resource{
+	List: RequireScope(
+		ScopeAdmin,
+	)(apiMaintenanceWindowsList),
+
+	Create: RequireScope(
+		ScopeAdmin,
+	)(apiMaintenanceWindowCreate),
+
+	Show: RequireScope(
+		ScopeAdmin,
+	)(apiMaintenanceWindowShow),
+
+	Update: RequireScope(
+		ScopeAdmin,
+	)(apiMaintenanceWindowUpdate),
+
+	Destroy: RequireScope(
+		ScopeAdmin,
+	)(apiMaintenanceWindowDestroy),
+}
+
+// apiMaintenanceWindowsList lists the maintenance windows,
+// optionally filtered by backend and state.
+func apiMaintenanceWindowsList(
+	ctx context.Context,
+	api *API,
+) error {
+	q := store.Q()
+
+	// Query parameter filters
+	queryBackendID := api.QueryParam("backend_id")
+	if queryBackendID != "" {
+		q = q.Where("backend_id = ?", queryBackendID)
+	}
+	queryState := api.QueryParam("state")
+	if queryState != "" {
+		q = q.Where("state = ?", queryState)
+	}
+	q = q.OrderBy("starts_at ASC")
+
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	windows, err := store.GetMaintenanceWindows(ctx, tx, q)
+	if err != nil {
+		return err
+	}
+	return api.JSON(http.StatusOK, windows)
+}
+
+// apiMaintenanceWindowCreate schedules a new maintenance
+// window for a backend.
+func apiMaintenanceWindowCreate(
+	ctx context.Context,
+	api *API,
+) error {
+	w := &store.MaintenanceWindow{}
+	if err := api.Bind(w); err != nil {
+		return err
+	}
+
+	// Only allow create with well known fields
+	window := store.InitMaintenanceWindow(&store.MaintenanceWindow{
+		BackendID: w.BackendID,
+		Mode:      w.Mode,
+	})
+	setMaintenanceWindowTimes(window, w)
+	if err := window.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	backend, err := store.GetBackendState(ctx, tx, store.Q().
+		Where("id = ?", window.BackendID))
+	if err != nil {
+		return err
+	}
+	if backend == nil {
+		verr := store.ValidationError{}
+		verr.Add("backend_id", "no such backend")
+		return verr
+	}
+
+	if err := window.Save(ctx, tx); err != nil {
+		return err
+	}
+	if err := queueApplyMaintenanceWindow(ctx, tx, window); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return api.JSON(http.StatusOK, window)
+}
+
+// apiMaintenanceWindowShow retrieves a single
+// maintenance window by ID.
+func apiMaintenanceWindowShow(
+	ctx context.Context,
+	api *API,
+) error {
+	id := api.Param("id")
+
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	window, err := store.GetMaintenanceWindow(ctx, tx, store.Q().
+		Where("id = ?", id))
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return echo.ErrNotFound
+	}
+	return api.JSON(http.StatusOK, window)
+}
+
+// apiMaintenanceWindowUpdate changes the mode and the
+// times of a maintenance window, which is not done.
+func apiMaintenanceWindowUpdate(
+	ctx context.Context,
+	api *API,
+) error {
+	id := api.Param("id")
+
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	q := store.Q().Where("id = ?", id)
+	window, err := store.GetMaintenanceWindow(ctx, tx, q)
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return echo.ErrNotFound
+	}
+	update, err := store.GetMaintenanceWindow(ctx, tx, q)
+	if err != nil {
+		return err
+	}
+	if err := api.Bind(update); err != nil {
+		return err
+	}
+
+	if window.State == store.MaintenanceDone {
+		verr := store.ValidationError{}
+		verr.Add("state", "the maintenance window is done")
+		return verr
+	}
+
+	// Apply update for well known fields
+	window.Mode = update.Mode
+	setMaintenanceWindowTimes(window, update)
+	if err := window.Validate(); err != nil {
+		return err
+	}
+
+	if err := window.Save(ctx, tx); err != nil {
+		return err
+	}
+	if err := queueApplyMaintenanceWindow(ctx, tx, window); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return api.JSON(http.StatusOK, window)
+}
+
+// apiMaintenanceWindowDestroy removes a maintenance window.
+// An active window is ended instead, so the backend
+// is enabled again.
+func apiMaintenanceWindowDestroy(
+	ctx context.Context,
+	api *API,
+) error {
+	id := api.Param("id")
+
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	window, err := store.GetMaintenanceWindow(ctx, tx, store.Q().
+		Where("id = ?", id))
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return echo.ErrNotFound
+	}
+
+	if window.State == store.MaintenanceActive {
+		window.EndsAt = time.Now().UTC()
+		if window.Deadline != nil && window.Deadline.After(window.EndsAt) {
+			window.Deadline = nil
+		}
+		if err := window.Save(ctx, tx); err != nil {
+			return err
+		}
+		if err := queueApplyMaintenanceWindow(ctx, tx, window); err != nil {
+			return err
+		}
+	} else {
+		if err := window.Delete(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return api.JSON(http.StatusOK, window)
+}
+
+// setMaintenanceWindowTimes copies the times of the
+// maintenance window in UTC, as used in the store.
+func setMaintenanceWindowTimes(
+	window *store.MaintenanceWindow,
+	w *store.MaintenanceWindow,
+) {
+	window.StartsAt = w.StartsAt.UTC()
+	window.EndsAt = w.EndsAt.UTC()
+	window.Deadline = nil
+	if w.Deadline != nil {
+		deadline := w.Deadline.UTC()
+		window.Deadline = &deadline
+	}
+}
+
+// queueApplyMaintenanceWindow requests applying the window
+// right away, in case it already started or ended.
+func queueApplyMaintenanceWindow(
+	ctx context.Context,
+	tx pgx.Tx,
+	window *store.MaintenanceWindow,
+) error {
+	cmd := cluster.ApplyMaintenanceWindow(
+		&cluster.ApplyMaintenanceWindowRequest{
+			ID: window.ID,
+		})
+	return store.QueueCommand(ctx, tx, cmd)
+}
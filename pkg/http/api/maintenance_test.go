@@ -0,0 +1,78 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestMaintenanceWindowCreate(t *testing.T) {
+	setup, _ := NewTestRequest().
+		Authorize("admin42", ScopeAdmin).
+		Context()
+	b := createTestBackend(setup)
+	setup.Release()
+
+	start := time.Now().UTC().Add(time.Hour)
+	api, res := NewTestRequest().
+		Authorize("admin42", ScopeAdmin).
+		JSON(map[string]interface{}{
+			"backend_id": b.ID,
+			"mode":       "force",
+			"starts_at":  start,
+			"ends_at":    start.Add(2 * time.Hour),
+		}).
+		Context()
+	defer api.Release()
+
+	if err := api.Handle(ResourceMaintenanceWindows.Create); err != nil {
+		t.Fatal(err)
+	}
+	if err := res.StatusOK(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := res.JSON()
+	if body["state"].(string) != store.MaintenanceScheduled {
+		t.Error("unexpected state:", body["state"])
+	}
+	t.Log("create:", res.Body())
+}
+
+func TestMaintenanceWindowDestroy(t *testing.T) {
+	api, res := NewTestRequest().
+		Authorize("admin42", ScopeAdmin).
+		Context()
+	defer api.Release()
+
+	b := createTestBackend(api)
+	ctx := api.Ctx()
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().UTC().Add(time.Hour)
+	w := store.InitMaintenanceWindow(&store.MaintenanceWindow{
+		BackendID: b.ID,
+		StartsAt:  start,
+		EndsAt:    start.Add(time.Hour),
+	})
+	if err := w.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	api.SetParamNames("id")
+	api.SetParamValues(w.ID)
+
+	if err := api.Handle(ResourceMaintenanceWindows.Destroy); err != nil {
+		t.Fatal(err)
+	}
+	if err := res.StatusOK(); err != nil {
+		t.Error(err)
+	}
+	t.Log("destroy:", res.Body())
+}
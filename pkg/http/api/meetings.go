@@ -56,6 +56,9 @@ func apiMeetingsList(
 
 	// Begin Query
 	q := store.Q().Where("backend_id = ?", backend.ID)
+	if parentID := api.QueryParam("parent_id"); parentID != "" {
+		q = q.Where("parent_id = ?", parentID)
+	}
 	meetings, err := store.GetMeetingStates(ctx, tx, q)
 	if err != nil {
 		return err
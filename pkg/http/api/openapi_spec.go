@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
 	oa "github.com/b3scale/b3scale/pkg/openapi"
 	"github.com/b3scale/b3scale/pkg/store"
 	"github.com/b3scale/b3scale/pkg/store/schema"
@@ -128,6 +129,9 @@ func NewBackendsAPISchema() map[string]oa.Path {
 					oa.ParamQuery(
 						"host__ilike",
 						"List backends partially matching the host, case insensitive."),
+					oa.ParamQuery(
+						"idle",
+						"List only backends without meetings, when true. Idle backends can be shut down."),
 				},
 			},
 			"post": oa.Operation{
@@ -205,6 +209,100 @@ func NewBackendsAPISchema() map[string]oa.Path {
 	}
 }
 
+// NewMaintenanceWindowsAPISchema creates the endpoint
+// schema for maintenance windows
+func NewMaintenanceWindowsAPISchema() map[string]oa.Path {
+	return map[string]oa.Path{
+		"/v1/maintenance-windows": oa.Path{
+			"get": oa.Operation{
+				Description: "Fetch all maintenance windows",
+				OperationID: "maintenanceWindowsList",
+				Summary:     "List",
+				Tags:        []string{"Maintenance Windows"},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("MaintenanceWindows"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+				},
+				Parameters: []oa.Schema{
+					oa.ParamQuery(
+						"backend_id",
+						"List maintenance windows of this backend."),
+					oa.ParamQuery(
+						"state",
+						"List maintenance windows in this state: scheduled, active or done."),
+				},
+			},
+			"post": oa.Operation{
+				Description: "Schedule a maintenance window for a backend.\n\nThe backend is disabled at the start of the window and enabled again at the end. In force mode, the remaining meetings are ended at the deadline.",
+				OperationID: "maintenanceWindowsCreate",
+				Summary:     "Create",
+				Tags:        []string{"Maintenance Windows"},
+				RequestBody: &oa.Request{
+					Content: map[string]oa.MediaType{
+						oa.ApplicationJSON: oa.MediaType{
+							Schema: oa.SchemaRef("MaintenanceWindowRequest"),
+						},
+					},
+				},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("MaintenanceWindow"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+				},
+			},
+		},
+		"/v1/maintenance-windows/{id}": oa.Path{
+			"parameters": []oa.Schema{
+				oa.ParamID(),
+			},
+			"get": oa.Operation{
+				Description: "Fetch a single maintenance window identified by ID",
+				OperationID: "maintenanceWindowsRead",
+				Summary:     "Read",
+				Tags:        []string{"Maintenance Windows"},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("MaintenanceWindow"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+					"404": oa.ResponseRef("NotFoundError"),
+				},
+			},
+			"patch": oa.Operation{
+				Description: "Update the mode and the times of a maintenance window, which is not done",
+				OperationID: "maintenanceWindowsPatch",
+				Summary:     "Update",
+				Tags:        []string{"Maintenance Windows"},
+				RequestBody: &oa.Request{
+					Content: map[string]oa.MediaType{
+						oa.ApplicationJSON: oa.MediaType{
+							Schema: oa.SchemaRef("MaintenanceWindowPatch"),
+						},
+					},
+				},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("MaintenanceWindow"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+					"404": oa.ResponseRef("NotFoundError"),
+				},
+			},
+			"delete": oa.Operation{
+				Description: "Remove a maintenance window.\n\nAn active maintenance window is ended instead and the backend is enabled again.",
+				OperationID: "maintenanceWindowsDestroy",
+				Summary:     "Delete",
+				Tags:        []string{"Maintenance Windows"},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("MaintenanceWindow"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+					"404": oa.ResponseRef("NotFoundError"),
+				},
+			},
+		},
+	}
+}
+
 // NewMeetingsAPISchema create the endpoint schema for meetings
 func NewMeetingsAPISchema() map[string]oa.Path {
 	backendIDParam := oa.ParamQuery(
@@ -213,6 +311,9 @@ func NewMeetingsAPISchema() map[string]oa.Path {
 	backendHostParam := oa.ParamQuery(
 		"backend_host",
 		"The full host of the backend where the meetings are located. *Either this or `backend_id` is required.*")
+	parentIDParam := oa.ParamQuery(
+		"parent_id",
+		"Only list the breakout rooms of the meeting with this ID.")
 	return map[string]oa.Path{
 		"/v1/meetings": oa.Path{
 			"get": oa.Operation{
@@ -226,7 +327,7 @@ func NewMeetingsAPISchema() map[string]oa.Path {
 					"401": oa.ResponseRef("InvalidJWTError"),
 				},
 				Parameters: []oa.Schema{
-					backendIDParam, backendHostParam,
+					backendIDParam, backendHostParam, parentIDParam,
 				},
 			},
 		},
@@ -295,9 +396,17 @@ func NewCommandsAPISchema() map[string]oa.Path {
 					"400": oa.ResponseRef("BadRequest"),
 					"401": oa.ResponseRef("InvalidJWTError"),
 				},
+				Parameters: []oa.Schema{
+					oa.ParamQuery(
+						"state",
+						"List commands in this state. Use `failed` to list commands which exhausted all attempts or expired."),
+					oa.ParamQuery(
+						"action",
+						"List commands with this action."),
+				},
 			},
 			"post": oa.Operation{
-				Description: "Insert a new command into the queue.\n\nCurrently only `end_all_meetings` for a given backend is supported.\n\nExample: `{\"action\": \"end_all_meetings\", \"params\": {\"BackendID\": \"b056bc5e-372e-4562-b23a-bd6a92634e7b\"}}`",
+				Description: "Insert a new command into the queue.\n\nCurrently `end_all_meetings` for a given backend and `end_frontend_meetings` for a given frontend are supported.\n\nExample: `{\"action\": \"end_all_meetings\", \"params\": {\"BackendID\": \"b056bc5e-372e-4562-b23a-bd6a92634e7b\"}}`\n\nExample: `{\"action\": \"end_frontend_meetings\", \"params\": {\"FrontendID\": \"6a3b4b5d-1c2f-4e8a-9d0b-7f6e5d4c3b2a\"}}`",
 				OperationID: "commandsCreate",
 				Summary:     "Create",
 				Tags:        []string{"Commands"},
@@ -331,6 +440,52 @@ func NewCommandsAPISchema() map[string]oa.Path {
 					"404": oa.ResponseRef("NotFoundError"),
 				},
 			},
+			"patch": oa.Operation{
+				Description: "Requeue a failed command.\n\nThe command is run again with all attempts.\n\nExample: `{\"state\": \"requested\"}`",
+				OperationID: "commandsRequeue",
+				Summary:     "Requeue",
+				Tags:        []string{"Commands"},
+				RequestBody: &oa.Request{
+					Content: map[string]oa.MediaType{
+						oa.ApplicationJSON: oa.MediaType{
+							Schema: oa.SchemaRef("CommandRequeue"),
+						},
+					},
+				},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("Command"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+				},
+			},
+		},
+	}
+}
+
+// NewRoutingAPISchema creates the api schema for
+// evaluating the routing
+func NewRoutingAPISchema() map[string]oa.Path {
+	return map[string]oa.Path{
+		"/v1/routing/dry-run": oa.Path{
+			"post": oa.Operation{
+				Summary:     "Dry Run",
+				Description: "Select the backends for a hypothetical create request of a frontend and explain which routing middleware eliminated or reordered each backend. The frontend is identified by `frontend_id` or `frontend_key`. The default and override parameters of the frontend are applied. No meeting is created.\n\nExample: `{\"frontend_key\": \"frontend1\", \"params\": {\"maxParticipants\": \"250\"}}`",
+				OperationID: "routingDryRun",
+				Tags:        []string{"Routing"},
+				RequestBody: &oa.Request{
+					Content: map[string]oa.MediaType{
+						oa.ApplicationJSON: oa.MediaType{
+							Schema: oa.SchemaRef("RoutingDryRunRequest"),
+						},
+					},
+				},
+				Responses: oa.ResponseRefs{
+					"200": oa.ResponseRef("RoutingDryRunResult"),
+					"400": oa.ResponseRef("BadRequest"),
+					"401": oa.ResponseRef("InvalidJWTError"),
+					"404": oa.ResponseRef("NotFoundError"),
+				},
+			},
 		},
 	}
 }
@@ -463,8 +618,10 @@ func NewAPIEndpointsSchema() map[string]oa.Path {
 		NewMetaEndpointsSchema(),
 		NewFrontendsAPISchema(),
 		NewBackendsAPISchema(),
+		NewMaintenanceWindowsAPISchema(),
 		NewMeetingsAPISchema(),
 		NewCommandsAPISchema(),
+		NewRoutingAPISchema(),
 		NewRecordingsImportAPISchema(),
 		NewAgentAPISchema(),
 		NewCtrlEndpointsSchema(),
@@ -515,6 +672,22 @@ func NewAPIResponses() map[string]oa.Response {
 				},
 			},
 		},
+		"MaintenanceWindows": oa.Response{
+			Description: "List of Maintenance Windows",
+			Content: map[string]oa.MediaType{
+				oa.ApplicationJSON: oa.MediaType{
+					Schema: oa.SchemaRef("MaintenanceWindows"),
+				},
+			},
+		},
+		"MaintenanceWindow": oa.Response{
+			Description: "Maintenance Window",
+			Content: map[string]oa.MediaType{
+				oa.ApplicationJSON: oa.MediaType{
+					Schema: oa.SchemaRef("MaintenanceWindow"),
+				},
+			},
+		},
 
 		"Meetings": oa.Response{
 			Description: "List of Meetings",
@@ -541,6 +714,14 @@ func NewAPIResponses() map[string]oa.Response {
 				},
 			},
 		},
+		"RoutingDryRunResult": oa.Response{
+			Description: "Routing Dry Run Result",
+			Content: map[string]oa.MediaType{
+				oa.ApplicationJSON: oa.MediaType{
+					Schema: oa.SchemaRef("RoutingDryRunResult"),
+				},
+			},
+		},
 		"Command": oa.Response{
 			Description: "Command",
 			Content: map[string]oa.MediaType{
@@ -649,6 +830,14 @@ func NewAPISchemas() map[string]oa.Schema {
 			"Frontend Settings",
 			store.FrontendSettings{}).
 			RequireFrom(store.FrontendSettings{}),
+		"TagPreference": oa.ObjectSchema(
+			"Tag Preference",
+			store.TagPreference{}).
+			RequireFrom(store.TagPreference{}),
+		"RoutingRule": oa.ObjectSchema(
+			"Routing Rule",
+			store.RoutingRule{}).
+			RequireFrom(store.RoutingRule{}),
 		"DefaultPresentationSettings": oa.ObjectSchema(
 			"Default Presentation",
 			store.DefaultPresentationSettings{}).
@@ -677,6 +866,27 @@ func NewAPISchemas() map[string]oa.Schema {
 			"Backend Settings ",
 			store.BackendSettings{}).
 			RequireFrom(store.BackendSettings{}),
+		"CircuitBreaker": oa.ObjectSchema(
+			"Backend Circuit Breaker",
+			store.CircuitBreaker{}).
+			RequireFrom(store.CircuitBreaker{}),
+
+		"MaintenanceWindows": oa.ArraySchema(
+			"List of Maintenance Windows",
+			oa.SchemaRef("MaintenanceWindow")),
+		"MaintenanceWindowRequest": oa.ObjectSchema(
+			"Maintenance Window Request",
+			store.MaintenanceWindow{}).
+			Require("backend_id", "starts_at", "ends_at").
+			Only("backend_id", "mode", "starts_at", "ends_at", "deadline"),
+		"MaintenanceWindowPatch": oa.ObjectSchema(
+			"Maintenance Window Update",
+			store.MaintenanceWindow{}).
+			Only("mode", "starts_at", "ends_at", "deadline"),
+		"MaintenanceWindow": oa.ObjectSchema(
+			"Maintenance Window",
+			store.MaintenanceWindow{}).
+			RequireFrom(store.MaintenanceWindow{}),
 
 		"Meetings": oa.ArraySchema(
 			"List of Meetings",
@@ -715,11 +925,31 @@ func NewAPISchemas() map[string]oa.Schema {
 			store.Command{}).
 			RequireFrom(store.Command{}).
 			Nullable("result", "started_at", "stopped_at"),
+		"RoutingDryRunRequest": oa.ObjectSchema(
+			"Routing Dry Run Request",
+			RoutingDryRunRequest{}),
+		"RoutingDryRunResult": oa.ObjectSchema(
+			"Routing Dry Run Result",
+			RoutingDryRunResult{}).
+			Require("params", "rule_tags", "backends"),
+		"RoutingDryRunBackend": oa.ObjectSchema(
+			"Routing Dry Run Backend",
+			RoutingDryRunBackend{}).
+			Require("id", "host", "tags", "stress", "decisions"),
+		"RoutingDecision": oa.ObjectSchema(
+			"Routing Decision",
+			cluster.RoutingDecision{}).
+			Require("middleware", "backend_id", "action", "reason"),
 		"CommandRequest": oa.ObjectSchema(
 			"Command Request",
 			store.Command{}).
-			Only("action", "params").
+			Only("action", "params", "max_attempts", "priority").
 			Require("action", "params"),
+		"CommandRequeue": oa.ObjectSchema(
+			"Command Requeue",
+			store.Command{}).
+			Only("state").
+			Require("state"),
 
 		"Recording": oa.ObjectSchema(
 			"Recording",
@@ -750,6 +980,14 @@ func NewAPISchemas() map[string]oa.Schema {
 			"SchemaStatus", schema.Status{}).
 			RequireFrom(schema.Status{}),
 
+		"LeaderLease": oa.ObjectSchema(
+			"LeaderLease", store.LeaderLease{}).
+			RequireFrom(store.LeaderLease{}),
+
+		"InstanceState": oa.ObjectSchema(
+			"InstanceState", store.InstanceState{}).
+			RequireFrom(store.InstanceState{}),
+
 		"MigrationState": oa.ObjectSchema(
 			"MigrationState", schema.MigrationState{}).
 			RequireFrom(schema.MigrationState{}),
@@ -925,6 +1163,10 @@ func NewAPISpec() *oa.Spec {
 				Name:        "Backends",
 				Description: "Big Blue Button (BBB) servers are called backends. Each is a node in the cluster, having an agent running.\n\nThe following endpoints are for managing backends.",
 			},
+			{
+				Name:        "Maintenance Windows",
+				Description: "Maintenance windows are scheduled periods, in which a backend does not accept new meetings. The cluster disables the backend at the start and enables it again at the end.",
+			},
 			{
 				Name:        "Meetings",
 				Description: "The meetings API can be used to update and query meetings. Creating new meetings is not supported at the time.",
@@ -935,7 +1177,11 @@ func NewAPISpec() *oa.Spec {
 			},
 			{
 				Name:        "Commands",
-				Description: "The commands API is used queue asynchronous commands. Currently only `end_all_meetings` for a given backend is supported.",
+				Description: "The commands API is used queue asynchronous commands. Currently `end_all_meetings` for a given backend and `end_frontend_meetings` for a given frontend are supported. Failed commands are retried with an exponential backoff and can be requeued when all attempts are exhausted.",
+			},
+			{
+				Name:        "Routing",
+				Description: "The routing API shows how new meetings of a frontend are distributed to the backends.",
 			},
 			{
 				Name:        "Agent",
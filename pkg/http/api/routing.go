@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/labstack/echo/v4"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/middlewares/requests"
+	"github.com/b3scale/b3scale/pkg/middlewares/routing"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// ResourceRoutingDryRun evaluates the routing for
+// a hypothetical create request.
+var ResourceRoutingDryRun = &Resource{
+	Create: RequireScope(
+		ScopeAdmin,
+	)(apiRoutingDryRun),
+}
+
+// RoutingDryRunRequest is a hypothetical create request
+// of a frontend, identified by its ID or key.
+type RoutingDryRunRequest struct {
+	FrontendID  string     `json:"frontend_id,omitempty" doc:"The ID of the frontend creating the meeting."`
+	FrontendKey string     `json:"frontend_key,omitempty" doc:"The key of the frontend creating the meeting. Used if no frontend_id is given."`
+	Params      bbb.Params `json:"params" doc:"The parameters of the create request."`
+}
+
+// RoutingDryRunBackend is a backend considered for
+// creating the meeting.
+type RoutingDryRunBackend struct {
+	ID           string                     `json:"id"`
+	Host         string                     `json:"host"`
+	Tags         []string                   `json:"tags"`
+	Stress       float64                    `json:"stress" doc:"The stress score of the backend. Lower is preferred."`
+	Rank         int                        `json:"rank,omitempty" doc:"The position of the backend in the selection, starting at 1. Eliminated backends have no rank."`
+	EliminatedBy string                     `json:"eliminated_by,omitempty" doc:"The routing middleware which removed the backend." example:"capacity"`
+	Decisions    []*cluster.RoutingDecision `json:"decisions" doc:"All decisions of the routing middlewares about the backend."`
+}
+
+// RoutingDryRunResult contains all backends considered
+// for the meeting and the decisions made about them.
+type RoutingDryRunResult struct {
+	Params   bbb.Params              `json:"params" doc:"The parameters of the create request after applying the default and override parameters of the frontend."`
+	RuleTags []string                `json:"rule_tags" doc:"Tags required by the matching routing rules of the frontend."`
+	Backends []*RoutingDryRunBackend `json:"backends" doc:"The selected backends in order of preference, followed by the eliminated backends. The meeting would be created on the first backend."`
+	Error    string                  `json:"error,omitempty" doc:"The reason why no backend could be selected."`
+}
+
+// routingContext prepares a context and a create request
+// for routing a hypothetical meeting of a frontend.
+func routingContext(
+	ctx context.Context,
+	api *API,
+	q sq.SelectBuilder,
+	params bbb.Params,
+) (context.Context, *bbb.Request, *store.FrontendState, error) {
+	tx, err := api.Conn.Begin(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+	fstate, err := store.GetFrontendState(ctx, tx, q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if fstate == nil {
+		return nil, nil, nil, echo.ErrNotFound
+	}
+	tx.Rollback(ctx)
+
+	if params == nil {
+		params = bbb.Params{}
+	}
+	createReq := bbb.CreateRequest(params, nil).
+		WithFrontend(fstate.Frontend)
+
+	// Apply the frontend settings like a create request
+	frontend := cluster.NewFrontend(fstate)
+	requests.UpdateCreateParams(createReq, frontend)
+
+	routingCtx := store.ContextWithConnection(ctx, api.Conn)
+	routingCtx = cluster.ContextWithFrontend(routingCtx, frontend)
+
+	return routingCtx, createReq, fstate, nil
+}
+
+// apiRoutingDryRun selects the backends for a create
+// request without creating a meeting, and reports
+// the decisions of the routing middlewares for all
+// backends considered.
+func apiRoutingDryRun(ctx context.Context, api *API) error {
+	if api.Router == nil {
+		return echo.ErrServiceUnavailable
+	}
+	req := &RoutingDryRunRequest{}
+	if err := api.Bind(req); err != nil {
+		return err
+	}
+	var q sq.SelectBuilder
+	switch {
+	case req.FrontendID != "":
+		q = store.Q().Where("id = ?", req.FrontendID)
+	case req.FrontendKey != "":
+		q = store.Q().Where("key = ?", req.FrontendKey)
+	default:
+		return store.ValidationError{
+			"frontend_id": []string{store.ErrFieldRequired},
+		}
+	}
+
+	routingCtx, createReq, fstate, err := routingContext(
+		ctx, api, q, req.Params)
+	if err != nil {
+		return err
+	}
+	trace := cluster.NewRoutingTrace()
+	routingCtx = cluster.ContextWithRoutingTrace(routingCtx, trace)
+
+	result := &RoutingDryRunResult{
+		Params:   createReq.Params,
+		RuleTags: routing.RuleTags(&fstate.Settings, createReq.Params),
+		Backends: []*RoutingDryRunBackend{},
+	}
+	selected, err := api.Router.SelectBackends(routingCtx, createReq)
+	if isRoutingError(err) {
+		result.Error = err.Error()
+	} else if err != nil {
+		return err
+	}
+
+	ranks := make(map[string]int, len(selected))
+	for i, be := range selected {
+		ranks[be.ID()] = i + 1
+		result.Backends = append(result.Backends,
+			dryRunBackend(trace, be, i+1))
+	}
+	for _, be := range trace.Candidates {
+		if _, ok := ranks[be.ID()]; ok {
+			continue
+		}
+		result.Backends = append(result.Backends,
+			dryRunBackend(trace, be, 0))
+	}
+
+	return api.JSON(http.StatusOK, result)
+}
+
+// dryRunBackend creates the result for a backend
+// from the routing trace.
+func dryRunBackend(
+	trace *cluster.RoutingTrace,
+	be *cluster.Backend,
+	rank int,
+) *RoutingDryRunBackend {
+	stress, ok := trace.Stress(be)
+	if !ok {
+		stress = be.Stress()
+	}
+	return &RoutingDryRunBackend{
+		ID:           be.ID(),
+		Host:         be.Host(),
+		Tags:         be.Tags(),
+		Stress:       stress,
+		Rank:         rank,
+		EliminatedBy: trace.EliminatedBy(be),
+		Decisions:    trace.BackendDecisions(be),
+	}
+}
+
+// isRoutingError checks if the error is an expected
+// result of the routing.
+func isRoutingError(err error) bool {
+	return errors.Is(err, cluster.ErrNoBackendAvailable) ||
+		errors.Is(err, cluster.ErrNoBackendCapacity) ||
+		errors.Is(err, cluster.ErrParentMeetingUnknown) ||
+		errors.Is(err, cluster.ErrParentBackendUnavailable)
+}
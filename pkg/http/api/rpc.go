@@ -99,6 +99,7 @@ const (
 	ActionMeetingSetRunning     = "meeting_set_running"
 	ActionMeetingAddAttendee    = "meeting_add_attendee"
 	ActionMeetingRemoveAttendee = "meeting_remove_attendee"
+	ActionMeetingAddBreakout    = "meeting_add_breakout"
 )
 
 // Payloads
@@ -128,6 +129,13 @@ type MeetingRemoveAttendeeRequest struct {
 	InternalUserID    string `json:"internal_user_id"`
 }
 
+// MeetingAddBreakoutRequest will register a breakout room
+// with its parent meeting
+type MeetingAddBreakoutRequest struct {
+	ParentInternalMeetingID string            `json:"parent_internal_meeting_id"`
+	Breakout                *bbb.BreakoutInfo `json:"breakout"`
+}
+
 // Action Creators
 
 // RPCMeetingStateReset creates an meeting state reset request
@@ -150,6 +158,11 @@ func RPCMeetingRemoveAttendee(params *MeetingRemoveAttendeeRequest) *RPCRequest
 	return NewRPCRequest(ActionMeetingRemoveAttendee, params)
 }
 
+// RPCMeetingAddBreakout creates an add breakout request
+func RPCMeetingAddBreakout(params *MeetingAddBreakoutRequest) *RPCRequest {
+	return NewRPCRequest(ActionMeetingAddBreakout, params)
+}
+
 // Dispatch will invoke the RPC handlers with the decoded
 // request payload.
 func (rpc *RPCRequest) Dispatch(
@@ -188,6 +201,13 @@ func (rpc *RPCRequest) Dispatch(
 		}
 		result, err = handler.MeetingRemoveAttendee(ctx, req)
 
+	case ActionMeetingAddBreakout:
+		req := &MeetingAddBreakoutRequest{}
+		if err := json.Unmarshal(rpc.Payload, &req); err != nil {
+			return RPCError(err)
+		}
+		result, err = handler.MeetingAddBreakout(ctx, req)
+
 	default:
 		err = ErrInvalidAction
 	}
@@ -397,3 +417,78 @@ var ResourceAgentRPC = &Resource{
 		return api.JSON(http.StatusOK, res)
 	}),
 }
+
+// MeetingAddBreakout registers a breakout room with
+// its parent meeting. The breakout room is bound to the
+// backend and frontend of the parent meeting.
+func (rpc *RPCHandler) MeetingAddBreakout(
+	ctx context.Context,
+	req *MeetingAddBreakoutRequest,
+) (RPCResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	parent, tx, err := store.AwaitMeetingState(ctx, rpc.Conn, store.Q().
+		Where("meetings.backend_id = ?", rpc.Backend.ID).
+		Where("meetings.internal_id = ?", req.ParentInternalMeetingID))
+	if errors.Is(err, context.DeadlineExceeded) {
+		rpc.logMeetingNotFound(req.ParentInternalMeetingID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	info := req.Breakout
+	breakout, err := store.GetMeetingState(ctx, tx, store.Q().
+		Where("meetings.internal_id = ?", info.BreakoutID))
+	if err != nil {
+		return nil, err
+	}
+	if breakout == nil {
+		breakout = store.InitMeetingState(&store.MeetingState{
+			ID:         info.ExternalID,
+			InternalID: info.BreakoutID,
+			Meeting: &bbb.Meeting{
+				MeetingID:         info.ExternalID,
+				InternalMeetingID: info.BreakoutID,
+				MeetingName:       info.Name,
+			},
+		})
+	}
+
+	// Update state
+	breakout.BackendID = parent.BackendID
+	if breakout.FrontendID == nil {
+		breakout.FrontendID = parent.FrontendID
+	}
+	breakout.ParentID = &parent.ID
+	breakout.Meeting.IsBreakout = true
+	breakout.Meeting.Breakout = &bbb.Breakout{
+		ParentMeetingID: parent.Meeting.InternalMeetingID,
+		Sequence:        info.Sequence,
+		FreeJoin:        info.FreeJoin,
+	}
+
+	known := false
+	for _, id := range parent.Meeting.BreakoutRooms {
+		if id == info.BreakoutID {
+			known = true
+		}
+	}
+	if !known {
+		parent.Meeting.BreakoutRooms = append(
+			parent.Meeting.BreakoutRooms, info.BreakoutID)
+	}
+
+	if err := breakout.Save(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := parent.Save(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
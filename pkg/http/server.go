@@ -34,6 +34,7 @@ type Server struct {
 	echo       *echo.Echo
 	gateway    *cluster.Gateway
 	controller *cluster.Controller
+	router     *cluster.Router
 }
 
 // NewServer configures and creates a new http interface
@@ -42,6 +43,7 @@ func NewServer(
 	serviceID string,
 	ctrl *cluster.Controller,
 	gateway *cluster.Gateway,
+	router *cluster.Router,
 ) *Server {
 	// Setup and configure echo framework
 	e := echo.New()
@@ -70,6 +72,7 @@ func NewServer(
 		echo:       e,
 		gateway:    gateway,
 		controller: ctrl,
+		router:     router,
 	}
 
 	// Register routes
@@ -78,7 +81,7 @@ func NewServer(
 	e.GET("/static/*", echo.WrapHandler(static.AssetsHTTPHandler("/static")))
 	e.GET("/b3s/retry-join/:req", s.httpRetryJoin)
 
-	if err := api.Init(e); err != nil {
+	if err := api.Init(e, router); err != nil {
 		log.Warn().Err(err).Msg("could not initialize rest API")
 	}
 
@@ -0,0 +1,276 @@
+package requests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// FrontendQuotas enforces the limits of a frontend
+// defined in the frontend settings:
+//
+//   max_concurrent_meetings = 20
+//   max_attendees = 500
+//   max_meeting_duration = 240
+//
+// Creating a new meeting fails, when the frontend reached
+// the number of concurrent meetings. A new meeting is
+// reserved for the frontend until the create is done,
+// so concurrent creates can not exceed the limit.
+// Joining a meeting fails, when the frontend reached the
+// number of attendees. As the attendees are only known
+// after the join, this limit is approximate.
+// The duration of a meeting is limited to the maximum
+// duration in minutes.
+//
+func FrontendQuotas() cluster.RequestMiddleware {
+	return func(next cluster.RequestHandler) cluster.RequestHandler {
+		return func(
+			ctx context.Context,
+			req *bbb.Request,
+		) (bbb.Response, error) {
+			if req.Resource != bbb.ResourceCreate &&
+				req.Resource != bbb.ResourceJoin {
+				return next(ctx, req) // nothing to do here
+			}
+			frontend := cluster.FrontendFromContext(ctx)
+			if frontend == nil {
+				return next(ctx, req) // pass
+			}
+			settings := frontend.Settings()
+
+			if req.Resource == bbb.ResourceCreate {
+				limitMeetingDuration(req, settings.MaxMeetingDuration)
+			}
+			if !hasQuotas(settings) {
+				return next(ctx, req) // pass
+			}
+
+			if req.Resource == bbb.ResourceCreate {
+				return createWithQuota(ctx, req, frontend, next)
+			}
+			res, err := checkFrontendQuotas(ctx, req, frontend)
+			if err != nil {
+				return nil, err
+			}
+			if res != nil {
+				return res, nil
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// hasQuotas checks if the frontend has any limit
+// on meetings or attendees.
+func hasQuotas(settings *store.FrontendSettings) bool {
+	return settings.MaxConcurrentMeetings > 0 ||
+		settings.MaxAttendees > 0
+}
+
+// createWithQuota checks the meetings limit of the frontend
+// for a new meeting. The meeting is reserved before the
+// create and the reservation is released afterwards, when
+// the meeting state was written or the create failed.
+func createWithQuota(
+	ctx context.Context,
+	req *bbb.Request,
+	frontend *cluster.Frontend,
+	next cluster.RequestHandler,
+) (bbb.Response, error) {
+	max := frontend.Settings().MaxConcurrentMeetings
+	if max <= 0 {
+		return next(ctx, req) // pass
+	}
+	meetingID, ok := req.Params.MeetingID()
+	if !ok {
+		return next(ctx, req) // the create will fail
+	}
+
+	// Creating a meeting which is already running
+	// does not start a new meeting.
+	exists, err := meetingExists(ctx, meetingID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return next(ctx, req)
+	}
+
+	reserved, err := reserveFrontendMeeting(ctx, frontend, meetingID, max)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		log.Info().
+			Str("frontend", frontend.Key()).
+			Str("resource", req.Resource).
+			Int("meetings", max).
+			Msg("frontend quota exceeded")
+		return maxMeetingsReachedResponse(), nil
+	}
+	defer releaseFrontendReservation(ctx, frontend, meetingID)
+
+	return next(ctx, req)
+}
+
+// meetingExists checks if the meeting is known
+func meetingExists(ctx context.Context, meetingID string) (bool, error) {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	meeting, err := store.GetMeetingStateByID(ctx, tx, meetingID)
+	if err != nil {
+		return false, err
+	}
+	return meeting != nil, nil
+}
+
+// reserveFrontendMeeting reserves a new meeting for the
+// frontend, if the limit is not reached.
+func reserveFrontendMeeting(
+	ctx context.Context,
+	frontend *cluster.Frontend,
+	meetingID string,
+	max int,
+) (bool, error) {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	reserved, err := store.ReserveFrontendMeeting(
+		ctx, tx, frontend.ID(), meetingID, max)
+	if err != nil || !reserved {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// releaseFrontendReservation removes the reservation of the
+// meeting. Errors are only logged, as the reservation expires.
+func releaseFrontendReservation(
+	ctx context.Context,
+	frontend *cluster.Frontend,
+	meetingID string,
+) {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("release frontend reservation")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := store.ReleaseFrontendReservation(
+		ctx, tx, frontend.ID(), meetingID); err != nil {
+		log.Error().Err(err).Msg("release frontend reservation")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("release frontend reservation")
+	}
+}
+
+// checkFrontendQuotas retrieves the usage of the frontend
+// and returns an error response if the request exceeds
+// a quota.
+func checkFrontendQuotas(
+	ctx context.Context,
+	req *bbb.Request,
+	frontend *cluster.Frontend,
+) (bbb.Response, error) {
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	usage, err := store.GetFrontendUsage(ctx, tx, frontend.ID())
+	if err != nil {
+		return nil, err
+	}
+	res := quotaExceededResponse(req, frontend.Settings(), usage)
+	if res != nil {
+		log.Info().
+			Str("frontend", frontend.Key()).
+			Str("resource", req.Resource).
+			Uint("meetings", usage.Meetings).
+			Uint("attendees", usage.Attendees).
+			Msg("frontend quota exceeded")
+	}
+	return res, nil
+}
+
+// quotaExceededResponse checks the usage of the frontend
+// against the limits. If the request would exceed a limit,
+// an error response is returned.
+func quotaExceededResponse(
+	req *bbb.Request,
+	settings *store.FrontendSettings,
+	usage *store.FrontendUsage,
+) bbb.Response {
+	switch req.Resource {
+	case bbb.ResourceCreate:
+		max := settings.MaxConcurrentMeetings
+		if max > 0 && usage.Meetings >= uint(max) {
+			return maxMeetingsReachedResponse()
+		}
+	case bbb.ResourceJoin:
+		max := settings.MaxAttendees
+		if max > 0 && usage.Attendees >= uint(max) {
+			return maxAttendeesReachedResponse()
+		}
+	}
+	return nil
+}
+
+// limitMeetingDuration sets the duration parameter of a
+// create request, if it is missing or exceeds the limit.
+// The duration is in minutes, 0 means unlimited.
+func limitMeetingDuration(req *bbb.Request, max int) {
+	if max <= 0 {
+		return
+	}
+	duration, err := strconv.Atoi(req.Params[bbb.ParamDuration])
+	if err == nil && duration > 0 && duration <= max {
+		return
+	}
+	req.Params[bbb.ParamDuration] = strconv.Itoa(max)
+}
+
+// maxMeetingsReachedResponse is the error response, when
+// the frontend reached the number of concurrent meetings.
+func maxMeetingsReachedResponse() *bbb.XMLResponse {
+	res := &bbb.XMLResponse{
+		Returncode: bbb.RetFailed,
+		Message:    "The maximum number of concurrent meetings is reached. Please try again later.",
+		MessageKey: "b3scaleMaxMeetingsReached",
+	}
+	res.SetStatus(http.StatusOK)
+	return res
+}
+
+// maxAttendeesReachedResponse is the error response, when
+// the frontend reached the number of attendees.
+func maxAttendeesReachedResponse() *bbb.XMLResponse {
+	res := &bbb.XMLResponse{
+		Returncode: bbb.RetFailed,
+		Message:    "The maximum number of attendees is reached. Please try again later.",
+		MessageKey: "b3scaleMaxAttendeesReached",
+	}
+	res.SetStatus(http.StatusOK)
+	return res
+}
@@ -0,0 +1,72 @@
+package requests
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestQuotaExceededResponse(t *testing.T) {
+	settings := &store.FrontendSettings{
+		MaxConcurrentMeetings: 2,
+		MaxAttendees:          10,
+	}
+	create := &bbb.Request{Resource: bbb.ResourceCreate}
+	join := &bbb.Request{Resource: bbb.ResourceJoin}
+
+	usage := &store.FrontendUsage{Meetings: 1, Attendees: 9}
+	if res := quotaExceededResponse(create, settings, usage); res != nil {
+		t.Error("unexpected response:", res)
+	}
+	if res := quotaExceededResponse(join, settings, usage); res != nil {
+		t.Error("unexpected response:", res)
+	}
+
+	usage = &store.FrontendUsage{Meetings: 2, Attendees: 10}
+	res := quotaExceededResponse(create, settings, usage)
+	if res == nil {
+		t.Fatal("expected an error response")
+	}
+	if res.(*bbb.XMLResponse).MessageKey != "b3scaleMaxMeetingsReached" {
+		t.Error("unexpected response:", res)
+	}
+	res = quotaExceededResponse(join, settings, usage)
+	if res == nil {
+		t.Fatal("expected an error response")
+	}
+	if res.(*bbb.XMLResponse).MessageKey != "b3scaleMaxAttendeesReached" {
+		t.Error("unexpected response:", res)
+	}
+
+	// Unlimited
+	settings = &store.FrontendSettings{}
+	if res := quotaExceededResponse(create, settings, usage); res != nil {
+		t.Error("unexpected response:", res)
+	}
+}
+
+func TestLimitMeetingDuration(t *testing.T) {
+	req := &bbb.Request{Params: bbb.Params{}}
+	limitMeetingDuration(req, 0)
+	if _, ok := req.Params[bbb.ParamDuration]; ok {
+		t.Error("duration should not be set")
+	}
+
+	limitMeetingDuration(req, 240)
+	if req.Params[bbb.ParamDuration] != "240" {
+		t.Error("unexpected duration:", req.Params[bbb.ParamDuration])
+	}
+
+	req.Params[bbb.ParamDuration] = "60"
+	limitMeetingDuration(req, 240)
+	if req.Params[bbb.ParamDuration] != "60" {
+		t.Error("unexpected duration:", req.Params[bbb.ParamDuration])
+	}
+
+	req.Params[bbb.ParamDuration] = "0"
+	limitMeetingDuration(req, 240)
+	if req.Params[bbb.ParamDuration] != "240" {
+		t.Error("unexpected duration:", req.Params[bbb.ParamDuration])
+	}
+}
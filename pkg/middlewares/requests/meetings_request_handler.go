@@ -2,8 +2,11 @@ package requests
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/b3scale/b3scale/pkg/bbb"
 	"github.com/b3scale/b3scale/pkg/cluster"
 	"github.com/b3scale/b3scale/pkg/store"
@@ -19,6 +22,11 @@ type MeetingsHandlerOptions struct {
 	// When deployed in reverse proxy mode we will handle the
 	// join internally and the proxy needs to handle subsequent requests.
 	UseReverseProxy bool
+
+	// CreateAttempts is the maximum number of backends
+	// tried when creating a meeting. If the create fails
+	// on a backend, the next candidate is used.
+	CreateAttempts int
 }
 
 // MeetingsHandler will handle all meetings related API requests
@@ -101,7 +109,12 @@ func (h *MeetingsHandler) Join(
 	}
 
 	// We have a backend - yay! check that the backend is
-	// ok and the node agent is alive.
+	// ok and the node agent is alive. If the backend is dead,
+	// the meeting is recreated on another backend.
+	if backendState.IsNodeDead() {
+		tx.Rollback(ctx)
+		return h.recoverMeeting(ctx, req, meeting)
+	}
 	if !backendState.IsNodeReady() {
 		return retryJoinResponse(req), nil
 	}
@@ -111,8 +124,12 @@ func (h *MeetingsHandler) Join(
 		return nil, err
 	}
 
-	// Dispatch to backend
+	// Dispatch to backend, unless the backend is failing
+	// too many requests.
 	backend := cluster.NewBackend(backendState)
+	if !backend.AllowRequest(ctx) {
+		return retryJoinResponse(req), nil
+	}
 	if h.opts.UseReverseProxy {
 		return backend.JoinProxy(ctx, req)
 	}
@@ -125,24 +142,113 @@ func (h *MeetingsHandler) Create(
 	ctx context.Context,
 	req *bbb.Request,
 ) (bbb.Response, error) {
-	var (
-		backend *cluster.Backend
-		err     error
-	)
+	// Concurrent creates of the same meeting are serialized
+	// across all instances: The second request will wait
+	// and use the backend selected by the first.
+	lock, err := acquireCreateLock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
 	// Lookup backend, as we need to make this
 	// endpoint idempotent
-	backend, err = h.router.LookupBackend(ctx, req)
+	backend, err := h.router.LookupBackend(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	// When no backend is found, select a new one.
-	if backend == nil {
-		backend, err = h.router.SelectBackend(ctx, req)
+	if backend != nil {
+		return backend.Create(ctx, req)
+	}
+
+	// When no backend is found, select new candidates.
+	backends, err := h.router.SelectBackends(ctx, req)
+	if errors.Is(err, cluster.ErrNoBackendCapacity) {
+		return clusterFullResponse(), nil
+	}
+	if errors.Is(err, cluster.ErrParentMeetingUnknown) {
+		return unknownParentMeetingResponse(), nil
+	}
+	if errors.Is(err, cluster.ErrParentBackendUnavailable) {
+		return parentBackendUnavailableResponse(), nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return backend.Create(ctx, req)
+
+	return cluster.CreateOnBackends(
+		ctx, req, backends, h.opts.CreateAttempts)
+}
+
+// acquireCreateLock acquires the lock for creating
+// the meeting of the frontend.
+func acquireCreateLock(
+	ctx context.Context,
+	req *bbb.Request,
+) (*store.MeetingLock, error) {
+	meetingID, ok := req.Params.MeetingID()
+	if !ok {
+		return nil, cluster.ErrMeetingIDMissing
+	}
+	frontendKey := ""
+	if req.Frontend != nil {
+		frontendKey = req.Frontend.Key
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, store.MeetingLockTimeout)
+	defer cancel()
+	return store.AcquireMeetingLock(lockCtx, frontendKey, meetingID)
+}
+
+// recoverMeeting claims the meeting on a dead backend
+// and requests recreating it from the stored create request
+// on a healthy backend. Concurrent joins will not create the
+// meeting multiple times: They are stalled until the
+// meeting is available again.
+func (h *MeetingsHandler) recoverMeeting(
+	ctx context.Context,
+	req *bbb.Request,
+	meeting *store.MeetingState,
+) (bbb.Response, error) {
+	if meeting.CreateRequest == nil {
+		log.Warn().
+			Str("meetingID", meeting.ID).
+			Msg("can not recover meeting without create request")
+		return retryJoinResponse(req), nil
+	}
+	prevBackendID := meeting.BackendID
+
+	// Claim the meeting by removing the backend and
+	// queue the recovery in the same transaction.
+	tx, err := store.ConnectionFromContext(ctx).Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	claimed, err := meeting.RebindBackend(ctx, tx, prevBackendID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return retryJoinResponse(req), nil // already recovering
+	}
+	cmd := cluster.RecoverMeeting(&cluster.RecoverMeetingRequest{
+		ID:        meeting.ID,
+		BackendID: *prevBackendID,
+	})
+	if err := store.QueueCommand(ctx, tx, cmd); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Warn().
+		Str("meetingID", meeting.ID).
+		Str("backendID", *prevBackendID).
+		Msg("backend is dead, recovering meeting")
+
+	// The join will be retried on the new backend.
+	return retryJoinResponse(req), nil
 }
 
 // IsMeetingRunning will check on a backend if the meeting is still running
@@ -230,6 +336,8 @@ func (h *MeetingsHandler) GetMeetings(
 	}
 	tx.Rollback(ctx)
 
+	nestBreakoutRooms(mstates)
+
 	meetings := make([]*bbb.Meeting, 0, len(mstates))
 	for _, state := range mstates {
 		meetings = append(meetings, state.Meeting)
@@ -246,6 +354,38 @@ func (h *MeetingsHandler) GetMeetings(
 	return res, nil
 }
 
+// nestBreakoutRooms adds the internal meeting IDs of
+// breakout rooms to their parent meetings, in case the
+// backend did not report them.
+func nestBreakoutRooms(mstates []*store.MeetingState) {
+	parents := make(map[string]*bbb.Meeting, len(mstates))
+	for _, state := range mstates {
+		parents[state.ID] = state.Meeting
+	}
+	for _, state := range mstates {
+		if state.ParentID == nil {
+			continue
+		}
+		parent, ok := parents[*state.ParentID]
+		if !ok || parent == nil {
+			continue
+		}
+		parent.BreakoutRooms = appendUnique(
+			parent.BreakoutRooms, state.Meeting.InternalMeetingID)
+	}
+}
+
+// appendUnique appends a value to a list of strings,
+// if it is not already present.
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
 // retryJoinResponse makes a new JoinResponse with
 // a redirect to a waiting page. The original request will be
 // encoded and passed to the page as a parameter.
@@ -281,6 +421,43 @@ func unknownMeetingResponse() *bbb.XMLResponse {
 	return res
 }
 
+// clusterFullResponse is the error response, when there
+// is no backend with free capacity for creating a meeting.
+func clusterFullResponse() *bbb.XMLResponse {
+	res := &bbb.XMLResponse{
+		Returncode: bbb.RetFailed,
+		Message:    "All servers reached their capacity. Please try again later.",
+		MessageKey: "b3scaleClusterFull",
+	}
+	res.SetStatus(http.StatusOK)
+	return res
+}
+
+// unknownParentMeetingResponse is returned when a breakout
+// room should be created, but the parent meeting is not known.
+func unknownParentMeetingResponse() *bbb.XMLResponse {
+	res := &bbb.XMLResponse{
+		Returncode: bbb.RetFailed,
+		Message:    "The parent meeting of the breakout room is not known to us.",
+		MessageKey: "parentMeetingIDMissing",
+	}
+	res.SetStatus(http.StatusOK)
+	return res
+}
+
+// parentBackendUnavailableResponse is returned when a
+// breakout room should be created, but the backend of the
+// parent meeting does not accept requests.
+func parentBackendUnavailableResponse() *bbb.XMLResponse {
+	res := &bbb.XMLResponse{
+		Returncode: bbb.RetFailed,
+		Message:    "The server of the parent meeting is currently not available. Please try again later.",
+		MessageKey: "b3scaleParentBackendUnavailable",
+	}
+	res.SetStatus(http.StatusOK)
+	return res
+}
+
 // The unknownMeetingBrowserResponse renders a human readable 404 template
 // in case the meeting was not found.
 func unknownMeetingBrowserResponse() *bbb.JoinResponse {
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestNestBreakoutRooms(t *testing.T) {
+	parentID := "parent"
+	parent := &store.MeetingState{
+		ID: parentID,
+		Meeting: &bbb.Meeting{
+			MeetingID:         parentID,
+			InternalMeetingID: "parent-int",
+			BreakoutRooms:     []string{"room1-int"},
+		},
+	}
+	room1 := &store.MeetingState{
+		ID:       "room1",
+		ParentID: &parentID,
+		Meeting: &bbb.Meeting{
+			MeetingID:         "room1",
+			InternalMeetingID: "room1-int",
+		},
+	}
+	room2 := &store.MeetingState{
+		ID:       "room2",
+		ParentID: &parentID,
+		Meeting: &bbb.Meeting{
+			MeetingID:         "room2",
+			InternalMeetingID: "room2-int",
+		},
+	}
+
+	nestBreakoutRooms([]*store.MeetingState{room1, parent, room2})
+
+	rooms := parent.Meeting.BreakoutRooms
+	if len(rooms) != 2 {
+		t.Fatal("unexpected breakout rooms:", rooms)
+	}
+	if rooms[0] != "room1-int" || rooms[1] != "room2-int" {
+		t.Error("unexpected breakout rooms:", rooms)
+	}
+}
@@ -20,15 +20,15 @@ func SetCreateParams() cluster.RequestMiddleware {
 			if req.Resource != bbb.ResourceCreate {
 				return next(ctx, req) // pass, nothing to do here
 			}
-			updateCreateParams(req, frontend)
+			UpdateCreateParams(req, frontend)
 			return next(ctx, req)
 		}
 	}
 }
 
-// updateCreateParams applies parameter overrides and
-// adds default values.
-func updateCreateParams(req *bbb.Request, fe *cluster.Frontend) {
+// UpdateCreateParams applies parameter overrides and
+// adds default values of the frontend.
+func UpdateCreateParams(req *bbb.Request, fe *cluster.Frontend) {
 	defaults := fe.Settings().CreateDefaultParams
 	overrides := fe.Settings().CreateOverrideParams
 
@@ -28,7 +28,7 @@ func TestUpdateCreateParams(t *testing.T) {
 		},
 	}
 
-	updateCreateParams(req, fe)
+	UpdateCreateParams(req, fe)
 
 	if req.Params["userCameraCap"] != "2" {
 		t.Error("param should not have been touched",
@@ -64,7 +64,7 @@ func TestUpdateCreateParamsClearFeatures(t *testing.T) {
 		},
 	}
 
-	updateCreateParams(req, fe)
+	UpdateCreateParams(req, fe)
 
 	if req.Params["disabledFeatures"] != "captions,polls,chat" {
 		t.Error("unexpected disabled features", req.Params["disabledFeatures"])
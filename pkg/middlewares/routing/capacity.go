@@ -0,0 +1,57 @@
+package routing
+
+import (
+	"context"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+)
+
+// Capacity filters backends which reached one of the
+// limits defined in the backend settings:
+//
+//   max_meetings = 50
+//   max_attendees = 500
+//   max_video_streams = 200
+//
+// If there are backends, but none of them has capacity
+// left, the routing fails with ErrNoBackendCapacity.
+func Capacity(next cluster.RouterHandler) cluster.RouterHandler {
+	return func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+
+		// This middleware only applies to create meeting requests
+		if req.Resource != bbb.ResourceCreate {
+			return next(ctx, backends, req) // pass
+		}
+		if len(backends) == 0 {
+			return next(ctx, backends, req) // nothing to do here
+		}
+
+		filtered := filterCapacity(backends)
+		cluster.TraceFilter(ctx, "capacity", backends, filtered,
+			"capacity limit reached")
+		if len(filtered) == 0 {
+			return nil, cluster.ErrNoBackendCapacity
+		}
+
+		return next(ctx, filtered, req)
+	}
+}
+
+// filterCapacity removes all backends without
+// capacity for new meetings.
+func filterCapacity(
+	backends []*cluster.Backend,
+) []*cluster.Backend {
+	filtered := make([]*cluster.Backend, 0, len(backends))
+	for _, be := range backends {
+		if be.HasCapacity() {
+			filtered = append(filtered, be)
+		}
+	}
+	return filtered
+}
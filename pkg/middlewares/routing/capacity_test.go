@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestCapacity(t *testing.T) {
+	full := cluster.NewBackend(&store.BackendState{
+		ID:            "full",
+		MeetingsCount: 5,
+		Settings: store.BackendSettings{
+			MaxMeetings: 5,
+		},
+	})
+	free := cluster.NewBackend(&store.BackendState{
+		ID:            "free",
+		MeetingsCount: 4,
+		Settings: store.BackendSettings{
+			MaxMeetings: 5,
+		},
+	})
+
+	handler := Capacity(func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+		return backends, nil
+	})
+
+	ctx := context.Background()
+	req := &bbb.Request{Resource: bbb.ResourceCreate}
+
+	res, err := handler(ctx, []*cluster.Backend{full, free}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0] != free {
+		t.Error("unexpected:", res)
+	}
+
+	_, err = handler(ctx, []*cluster.Backend{full}, req)
+	if err != cluster.ErrNoBackendCapacity {
+		t.Error("unexpected error:", err)
+	}
+
+	// Eliminated backends are traced
+	trace := cluster.NewRoutingTrace()
+	tctx := cluster.ContextWithRoutingTrace(ctx, trace)
+	if _, err := handler(tctx, []*cluster.Backend{full, free}, req); err != nil {
+		t.Fatal(err)
+	}
+	if by := trace.EliminatedBy(full); by != "capacity" {
+		t.Error("unexpected eliminated by:", by)
+	}
+
+	// Other requests are not affected
+	req = &bbb.Request{Resource: bbb.ResourceJoin}
+	res, err = handler(ctx, []*cluster.Backend{full}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Error("unexpected:", res)
+	}
+}
@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+)
+
+// Latency excludes backends with a median latency above
+// the threshold from creating new meetings. Backends with
+// a latency above half of the threshold are ranked last.
+// Slow responses are an early sign of a node in trouble.
+//
+// When all backends exceed the threshold, the backends
+// are passed unfiltered.
+func Latency(threshold time.Duration) cluster.RouterMiddleware {
+	return func(next cluster.RouterHandler) cluster.RouterHandler {
+		return func(
+			ctx context.Context,
+			backends []*cluster.Backend,
+			req *bbb.Request,
+		) ([]*cluster.Backend, error) {
+			// This middleware only applies to create meeting requests
+			if req.Resource != bbb.ResourceCreate {
+				return next(ctx, backends, req) // pass
+			}
+			if threshold <= 0 {
+				return next(ctx, backends, req) // disabled
+			}
+
+			filtered := filterLatency(backends, threshold)
+			if len(filtered) == 0 {
+				log.Warn().
+					Dur("threshold", threshold).
+					Msg("all backends exceed the latency threshold")
+				filtered = backends
+			}
+			cluster.TraceFilter(ctx, "latency", backends, filtered,
+				"median latency above "+threshold.String())
+
+			before := append([]*cluster.Backend{}, filtered...)
+			sortLatencyPenalty(filtered, threshold/2)
+			cluster.TraceOrder(ctx, "latency", before, filtered,
+				"median latency above "+(threshold/2).String())
+
+			return next(ctx, filtered, req)
+		}
+	}
+}
+
+// filterLatency removes backends with a latency
+// above the threshold.
+func filterLatency(
+	backends []*cluster.Backend,
+	threshold time.Duration,
+) []*cluster.Backend {
+	filtered := make([]*cluster.Backend, 0, len(backends))
+	for _, be := range backends {
+		if be.Latency() > threshold {
+			continue
+		}
+		filtered = append(filtered, be)
+	}
+	return filtered
+}
+
+// sortLatencyPenalty moves backends with a latency
+// above the threshold to the end, while keeping the
+// order otherwise.
+func sortLatencyPenalty(
+	backends []*cluster.Backend,
+	threshold time.Duration,
+) {
+	sort.SliceStable(backends, func(i, j int) bool {
+		slowI := backends[i].Latency() > threshold
+		slowJ := backends[j].Latency() > threshold
+		return !slowI && slowJ
+	})
+}
@@ -0,0 +1,69 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestLatency(t *testing.T) {
+	slow := cluster.NewBackend(&store.BackendState{
+		ID: "slow",
+		LatencyHistory: []time.Duration{
+			700 * time.Millisecond,
+			800 * time.Millisecond,
+			900 * time.Millisecond,
+		},
+	})
+	sluggish := cluster.NewBackend(&store.BackendState{
+		ID: "sluggish",
+		LatencyHistory: []time.Duration{
+			400 * time.Millisecond,
+			300 * time.Millisecond,
+			400 * time.Millisecond,
+		},
+	})
+	fast := cluster.NewBackend(&store.BackendState{
+		ID: "fast",
+		LatencyHistory: []time.Duration{
+			10 * time.Millisecond,
+			2 * time.Second, // a single slow probe
+			20 * time.Millisecond,
+		},
+	})
+
+	handler := Latency(500 * time.Millisecond)(func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+		return backends, nil
+	})
+
+	ctx := context.Background()
+	req := &bbb.Request{Resource: bbb.ResourceCreate}
+
+	res, err := handler(ctx, []*cluster.Backend{slow, sluggish, fast}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatal("unexpected backends:", res)
+	}
+	if res[0].ID() != "fast" || res[1].ID() != "sluggish" {
+		t.Error("unexpected order:", res)
+	}
+
+	// All backends are slow
+	res, err = handler(ctx, []*cluster.Backend{slow}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Error("expected unfiltered backends:", res)
+	}
+}
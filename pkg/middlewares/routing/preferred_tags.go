@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"context"
+	"sort"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// PreferredTags ranks backends providing the preferred
+// tags from the frontend settings higher:
+//
+//   preferred_tags = [{"tag": "customer42", "weight": 2}]
+//
+// Backends are not removed, so other backends are used
+// when no preferred backend is available. The order of
+// backends with the same rank is preserved, so this
+// middleware should run after SortLoad.
+func PreferredTags(next cluster.RouterHandler) cluster.RouterHandler {
+	return func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+
+		// This middleware only applies to create meeting requests
+		if req.Resource != bbb.ResourceCreate {
+			return next(ctx, backends, req) // pass
+		}
+
+		frontend := cluster.FrontendFromContext(ctx)
+		if frontend == nil {
+			return next(ctx, backends, req) // pass
+		}
+
+		prefs := frontend.Settings().PreferredTags
+		if len(prefs) == 0 {
+			return next(ctx, backends, req) // pass
+		}
+
+		before := append([]*cluster.Backend{}, backends...)
+		sortPreferredTags(backends, prefs)
+		cluster.TraceOrder(ctx, "preferred_tags", before, backends,
+			"ranked by preferred tags")
+		return next(ctx, backends, req)
+	}
+}
+
+// tagPreferenceScore sums up the weights of all
+// preferred tags provided by the backend.
+func tagPreferenceScore(
+	backend *cluster.Backend,
+	prefs []*store.TagPreference,
+) float64 {
+	score := 0.0
+	for _, p := range prefs {
+		if p == nil || !backend.HasTag(p.Tag) {
+			continue
+		}
+		if p.Weight == 0 {
+			score += 1.0
+		} else {
+			score += p.Weight
+		}
+	}
+	return score
+}
+
+// sortPreferredTags orders the backends by their
+// tag preference score, highest first.
+func sortPreferredTags(
+	backends []*cluster.Backend,
+	prefs []*store.TagPreference,
+) {
+	scores := make(map[*cluster.Backend]float64, len(backends))
+	for _, be := range backends {
+		scores[be] = tagPreferenceScore(be, prefs)
+	}
+	sort.SliceStable(backends, func(i, j int) bool {
+		return scores[backends[i]] > scores[backends[j]]
+	})
+}
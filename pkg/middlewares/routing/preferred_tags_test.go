@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestSortPreferredTags(t *testing.T) {
+	backends := []*cluster.Backend{
+		cluster.NewBackend(&store.BackendState{
+			ID: "A",
+		}),
+		cluster.NewBackend(&store.BackendState{
+			ID: "B",
+			Settings: store.BackendSettings{
+				Tags: []string{"sip"},
+			},
+		}),
+		cluster.NewBackend(&store.BackendState{
+			ID: "C",
+		}),
+		cluster.NewBackend(&store.BackendState{
+			ID: "D",
+			Settings: store.BackendSettings{
+				Tags: []string{"customer42"},
+			},
+		}),
+	}
+	prefs := []*store.TagPreference{
+		{Tag: "customer42", Weight: 2},
+		{Tag: "sip"},
+	}
+
+	sortPreferredTags(backends, prefs)
+
+	expected := []string{"D", "B", "A", "C"}
+	for i, id := range expected {
+		if backends[i].ID() != id {
+			t.Error("unexpected backend at", i, ":", backends[i].ID())
+		}
+	}
+}
@@ -2,16 +2,31 @@ package routing
 
 import (
 	"context"
+	"strings"
+
+	"github.com/rs/zerolog/log"
 
 	"github.com/b3scale/b3scale/pkg/bbb"
 	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
 )
 
+// DefaultRequestTagsParam is the create parameter
+// with additional required tags for a meeting.
+var DefaultRequestTagsParam = bbb.MetaParam("b3scale-tags")
+
 // RequiredTags filters backends that match required
 // tags defined in the frontend settings by the variables
 //
 //   required_tags = ["sip", "foo"]
 //
+// Additional tags can be required for a single meeting
+// with a create parameter, if the tags are allowed for
+// the frontend:
+//
+//   allowed_request_tags = ["sip", "recording"]
+//   request_tags_param = "meta_b3scale-tags"
+//
 func RequiredTags(next cluster.RouterHandler) cluster.RouterHandler {
 	return func(
 		ctx context.Context,
@@ -30,10 +45,15 @@ func RequiredTags(next cluster.RouterHandler) cluster.RouterHandler {
 			return next(ctx, backends, req) // pass
 		}
 
-		tags := frontend.Settings().RequiredTags
-		backends = filterRequiredTags(backends, tags)
+		settings := frontend.Settings()
+		tags := make([]string, 0, len(settings.RequiredTags))
+		tags = append(tags, settings.RequiredTags...)
+		tags = append(tags, requestTags(settings, req)...)
+		filtered := filterRequiredTags(backends, tags)
+		cluster.TraceFilter(ctx, "required_tags", backends, filtered,
+			"missing required tags: "+strings.Join(tags, ", "))
 
-		return next(ctx, backends, req)
+		return next(ctx, filtered, req)
 	}
 }
 
@@ -52,3 +72,46 @@ func filterRequiredTags(
 	}
 	return filtered
 }
+
+// requestTags retrieves the tags from the request
+// parameter. Only tags allowed for the frontend
+// are returned.
+func requestTags(
+	settings *store.FrontendSettings,
+	req *bbb.Request,
+) []string {
+	param := settings.RequestTagsParam
+	if param == "" {
+		param = DefaultRequestTagsParam
+	}
+	value, ok := req.Params[param]
+	if !ok {
+		return nil
+	}
+
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !isAllowedTag(settings.AllowedRequestTags, tag) {
+			log.Warn().
+				Str("tag", tag).
+				Msg("ignoring requested tag not allowed for frontend")
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// isAllowedTag checks if the tag is in the allowlist
+func isAllowedTag(allowed []string, tag string) bool {
+	for _, t := range allowed {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,9 @@ package routing
 import (
 	"testing"
 
+	"github.com/b3scale/b3scale/pkg/bbb"
 	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
 )
 
 func TestFilterRequiredTags(t *testing.T) {
@@ -14,3 +16,32 @@ func TestFilterRequiredTags(t *testing.T) {
 		t.Error("unexpected:", filtered)
 	}
 }
+
+func TestRequestTags(t *testing.T) {
+	settings := &store.FrontendSettings{
+		AllowedRequestTags: []string{"sip", "recording"},
+	}
+	req := &bbb.Request{
+		Params: bbb.Params{
+			"meta_b3scale-tags": "sip, secret,,recording",
+		},
+	}
+	tags := requestTags(settings, req)
+	if len(tags) != 2 {
+		t.Fatal("unexpected tags:", tags)
+	}
+	if tags[0] != "sip" || tags[1] != "recording" {
+		t.Error("unexpected tags:", tags)
+	}
+
+	// Custom parameter
+	settings.RequestTagsParam = "meta_tags"
+	if tags := requestTags(settings, req); len(tags) != 0 {
+		t.Error("unexpected tags:", tags)
+	}
+	req.Params["meta_tags"] = "recording"
+	tags = requestTags(settings, req)
+	if len(tags) != 1 || tags[0] != "recording" {
+		t.Error("unexpected tags:", tags)
+	}
+}
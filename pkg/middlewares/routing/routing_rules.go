@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// RoutingRules filters backends by the tags required
+// through rules in the frontend settings, when the
+// condition matches the create parameters:
+//
+//   routing_rules = [
+//     {"if": "meta_course-type == exam", "require_tags": ["exam-nodes"]},
+//     {"if": "maxParticipants > 200", "require_tags": ["large"]}
+//   ]
+//
+func RoutingRules(next cluster.RouterHandler) cluster.RouterHandler {
+	return func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+
+		// This middleware only applies to create meeting requests
+		if req.Resource != bbb.ResourceCreate {
+			return next(ctx, backends, req) // pass
+		}
+
+		frontend := cluster.FrontendFromContext(ctx)
+		if frontend == nil {
+			return next(ctx, backends, req) // pass
+		}
+
+		tags := RuleTags(frontend.Settings(), req.Params)
+		if len(tags) == 0 {
+			return next(ctx, backends, req) // nothing to do here
+		}
+		filtered := filterRequiredTags(backends, tags)
+		cluster.TraceFilter(ctx, "routing_rules", backends, filtered,
+			"missing tags required by routing rules: "+strings.Join(tags, ", "))
+
+		return next(ctx, filtered, req)
+	}
+}
+
+// RuleTags evaluates the routing rules of the frontend
+// and returns the tags required by all matching rules.
+// Invalid rules are skipped.
+func RuleTags(
+	settings *store.FrontendSettings,
+	params bbb.Params,
+) []string {
+	tags := []string{}
+	for _, rule := range settings.RoutingRules {
+		if rule == nil {
+			continue
+		}
+		match, err := rule.Match(params)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("rule", rule.If).
+				Msg("skipping invalid routing rule")
+			continue
+		}
+		if match {
+			tags = append(tags, rule.RequireTags...)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,33 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestRuleTags(t *testing.T) {
+	settings := &store.FrontendSettings{
+		RoutingRules: []*store.RoutingRule{
+			{If: "meta_course-type == exam", RequireTags: store.Tags{"exam-nodes"}},
+			{If: "maxParticipants > 200", RequireTags: store.Tags{"large"}},
+			{If: "invalid", RequireTags: store.Tags{"never"}},
+		},
+	}
+
+	tags := RuleTags(settings, bbb.Params{
+		"meta_course-type": "exam",
+		"maxParticipants":  "300",
+	})
+	if len(tags) != 2 || tags[0] != "exam-nodes" || tags[1] != "large" {
+		t.Error("unexpected tags:", tags)
+	}
+
+	tags = RuleTags(settings, bbb.Params{
+		"maxParticipants": "30",
+	})
+	if len(tags) != 0 {
+		t.Error("unexpected tags:", tags)
+	}
+}
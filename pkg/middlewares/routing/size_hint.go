@@ -0,0 +1,173 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+// DefaultSizeHintParam is the create parameter
+// with the expected number of attendees of a meeting.
+var DefaultSizeHintParam = bbb.MetaParam("b3scale-expected-attendees")
+
+// SizeHint estimates the number of attendees of a new
+// meeting. The estimate is taken from the first available
+// source of:
+//
+//   the size hint parameter, e.g. meta_b3scale-expected-attendees=300
+//   the peak attendees of previous runs of the meeting
+//   the maxParticipants parameter
+//
+// The parameter can be changed in the frontend settings:
+//
+//   size_hint_param = "meta_expected-size"
+//
+// Backends where the meeting would exceed the attendees
+// limit are not considered, unless no backend would fit.
+// Meetings with at least largeMeetingSize attendees are
+// created on the backend with the most headroom. This
+// replaces the order by stress from SortLoad, including
+// a packing schedule, so the stress only decides between
+// backends with the same headroom.
+//
+func SizeHint(largeMeetingSize uint) cluster.RouterMiddleware {
+	return func(next cluster.RouterHandler) cluster.RouterHandler {
+		return func(
+			ctx context.Context,
+			backends []*cluster.Backend,
+			req *bbb.Request,
+		) ([]*cluster.Backend, error) {
+			// This middleware only applies to create meeting requests
+			if req.Resource != bbb.ResourceCreate {
+				return next(ctx, backends, req) // pass
+			}
+			if len(backends) == 0 {
+				return next(ctx, backends, req) // nothing to do here
+			}
+
+			var settings *store.FrontendSettings
+			if frontend := cluster.FrontendFromContext(ctx); frontend != nil {
+				settings = frontend.Settings()
+			}
+
+			var peak uint
+			if meetingID, ok := req.Params.MeetingID(); ok {
+				p, err := cluster.LookupPeakAttendees(ctx, meetingID)
+				if err != nil {
+					return nil, err
+				}
+				peak = p
+			}
+
+			size := estimateMeetingSize(settings, req.Params, peak)
+			if size == 0 {
+				return next(ctx, backends, req) // no estimate
+			}
+
+			filtered := filterFits(backends, size)
+			if len(filtered) == 0 {
+				log.Warn().
+					Uint("size", size).
+					Msg("meeting exceeds the attendees limit of all backends")
+				cluster.TraceKeep(ctx, "size_hint", backends,
+					fmt.Sprintf("%d expected attendees exceed the attendees limit of all backends", size))
+				filtered = backends
+			}
+			cluster.TraceFilter(ctx, "size_hint", backends, filtered,
+				fmt.Sprintf("%d expected attendees exceed the attendees limit", size))
+
+			if largeMeetingSize > 0 && size >= largeMeetingSize {
+				before := append([]*cluster.Backend{}, filtered...)
+				sortHeadroom(filtered)
+				cluster.TraceOrder(ctx, "size_hint", before, filtered,
+					fmt.Sprintf("%d expected attendees, sorted by headroom", size))
+			}
+
+			return next(ctx, filtered, req)
+		}
+	}
+}
+
+// estimateMeetingSize returns the expected number of
+// attendees for a meeting. If there is no estimate,
+// the size is 0.
+func estimateMeetingSize(
+	settings *store.FrontendSettings,
+	params bbb.Params,
+	peak uint,
+) uint {
+	param := DefaultSizeHintParam
+	if settings != nil && settings.SizeHintParam != "" {
+		param = settings.SizeHintParam
+	}
+	if size, ok := paramUint(params, param); ok {
+		return size
+	}
+	if peak > 0 {
+		return peak
+	}
+	if size, ok := paramUint(params, bbb.ParamMaxParticipants); ok {
+		return size
+	}
+	return 0
+}
+
+// paramUint parses a positive number from the params
+func paramUint(params bbb.Params, key string) (uint, bool) {
+	value, ok := params[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// filterFits removes backends where the meeting
+// would exceed the attendees limit.
+func filterFits(
+	backends []*cluster.Backend,
+	size uint,
+) []*cluster.Backend {
+	filtered := make([]*cluster.Backend, 0, len(backends))
+	for _, be := range backends {
+		headroom, ok := be.AttendeesHeadroom()
+		if ok && headroom < size {
+			continue
+		}
+		filtered = append(filtered, be)
+	}
+	return filtered
+}
+
+// sortHeadroom orders backends by the headroom for
+// attendees. Backends without an attendees limit are
+// assumed to have the limit of the largest limited
+// backend, so their headroom is comparable. If no backend
+// is limited, they are ordered by their attendees load.
+func sortHeadroom(backends []*cluster.Backend) {
+	var capacity float64
+	for _, be := range backends {
+		if limit, ok := be.AttendeesLimit(); ok && float64(limit) > capacity {
+			capacity = float64(limit)
+		}
+	}
+	headroom := func(be *cluster.Backend) float64 {
+		if h, ok := be.AttendeesHeadroom(); ok {
+			return float64(h)
+		}
+		return capacity - be.AttendeesLoad()
+	}
+	sort.SliceStable(backends, func(i, j int) bool {
+		return headroom(backends[i]) > headroom(backends[j])
+	})
+}
@@ -0,0 +1,110 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestEstimateMeetingSize(t *testing.T) {
+	params := bbb.Params{
+		bbb.ParamMaxParticipants: "500",
+	}
+	if size := estimateMeetingSize(nil, params, 0); size != 500 {
+		t.Error("unexpected size:", size)
+	}
+	if size := estimateMeetingSize(nil, params, 42); size != 42 {
+		t.Error("unexpected size:", size)
+	}
+	params[DefaultSizeHintParam] = "300"
+	if size := estimateMeetingSize(nil, params, 42); size != 300 {
+		t.Error("unexpected size:", size)
+	}
+
+	settings := &store.FrontendSettings{
+		SizeHintParam: "meta_size",
+	}
+	if size := estimateMeetingSize(settings, params, 42); size != 42 {
+		t.Error("unexpected size:", size)
+	}
+
+	params = bbb.Params{bbb.ParamMaxParticipants: "0"}
+	if size := estimateMeetingSize(nil, params, 0); size != 0 {
+		t.Error("unexpected size:", size)
+	}
+}
+
+func TestFilterFits(t *testing.T) {
+	small := cluster.NewBackend(&store.BackendState{
+		ID:             "small",
+		AttendeesCount: 80,
+		Settings: store.BackendSettings{
+			MaxAttendees: 100,
+		},
+	})
+	unlimited := cluster.NewBackend(&store.BackendState{
+		ID:             "unlimited",
+		AttendeesCount: 300,
+	})
+
+	res := filterFits([]*cluster.Backend{small, unlimited}, 50)
+	if len(res) != 1 || res[0] != unlimited {
+		t.Error("unexpected:", res)
+	}
+	res = filterFits([]*cluster.Backend{small, unlimited}, 20)
+	if len(res) != 2 {
+		t.Error("unexpected:", res)
+	}
+}
+
+func TestSortHeadroom(t *testing.T) {
+	small := cluster.NewBackend(&store.BackendState{
+		ID:             "small",
+		AttendeesCount: 300,
+		Settings: store.BackendSettings{
+			MaxAttendees: 500,
+		},
+	})
+	large := cluster.NewBackend(&store.BackendState{
+		ID:             "large",
+		AttendeesCount: 400,
+		Settings: store.BackendSettings{
+			MaxAttendees: 1000,
+		},
+	})
+	busy := cluster.NewBackend(&store.BackendState{
+		ID:             "busy",
+		AttendeesCount: 200,
+		LoadFactor:     1.0,
+	})
+	idle := cluster.NewBackend(&store.BackendState{
+		ID:             "idle",
+		AttendeesCount: 10,
+		LoadFactor:     1.0,
+	})
+
+	backends := []*cluster.Backend{small, large, busy, idle}
+	sortHeadroom(backends)
+	if backends[0] != idle ||
+		backends[1] != busy ||
+		backends[2] != large ||
+		backends[3] != small {
+		t.Error("unexpected order:", backends)
+	}
+
+	// Unlimited backends are not always ranked first
+	crowded := cluster.NewBackend(&store.BackendState{
+		ID:             "crowded",
+		AttendeesCount: 900,
+		LoadFactor:     1.0,
+	})
+	backends = []*cluster.Backend{crowded, small, large}
+	sortHeadroom(backends)
+	if backends[0] != large ||
+		backends[1] != small ||
+		backends[2] != crowded {
+		t.Error("unexpected order:", backends)
+	}
+}
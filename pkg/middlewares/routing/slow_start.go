@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"context"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+)
+
+// SlowStart limits the number of new meetings per minute
+// on backends which were enabled or recovered recently.
+// Otherwise a fresh backend without meetings would receive
+// all creates until the next node sync.
+//
+// When all backends reached the limit, the backends are
+// passed unfiltered.
+func SlowStart(
+	warmup time.Duration,
+	createsPerMinute uint,
+) cluster.RouterMiddleware {
+	return func(next cluster.RouterHandler) cluster.RouterHandler {
+		return func(
+			ctx context.Context,
+			backends []*cluster.Backend,
+			req *bbb.Request,
+		) ([]*cluster.Backend, error) {
+			// This middleware only applies to create meeting requests
+			if req.Resource != bbb.ResourceCreate {
+				return next(ctx, backends, req) // pass
+			}
+			if warmup <= 0 || createsPerMinute == 0 {
+				return next(ctx, backends, req) // disabled
+			}
+
+			filtered := filterSlowStart(backends, warmup, createsPerMinute)
+			if len(filtered) == 0 {
+				cluster.TraceKeep(ctx, "slow_start", backends,
+					"limit of new meetings during warm-up reached by all backends")
+				return next(ctx, backends, req)
+			}
+			cluster.TraceFilter(ctx, "slow_start", backends, filtered,
+				"limit of new meetings during warm-up reached")
+			return next(ctx, filtered, req)
+		}
+	}
+}
+
+// filterSlowStart removes backends in the warm-up
+// period which reached the limit of new meetings.
+func filterSlowStart(
+	backends []*cluster.Backend,
+	warmup time.Duration,
+	createsPerMinute uint,
+) []*cluster.Backend {
+	filtered := make([]*cluster.Backend, 0, len(backends))
+	for _, be := range backends {
+		if be.IsWarmingUp(warmup) &&
+			be.RecentCreates() >= createsPerMinute {
+			continue
+		}
+		filtered = append(filtered, be)
+	}
+	return filtered
+}
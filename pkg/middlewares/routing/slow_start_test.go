@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+	"github.com/b3scale/b3scale/pkg/cluster"
+	"github.com/b3scale/b3scale/pkg/store"
+)
+
+func TestSlowStart(t *testing.T) {
+	recent := time.Now().UTC().Add(-1 * time.Minute)
+	past := time.Now().UTC().Add(-1 * time.Hour)
+
+	warming := cluster.NewBackend(&store.BackendState{
+		ID:                 "warming",
+		ReadyAt:            &recent,
+		RecentCreatesCount: 2,
+		ReservationsCount:  1,
+	})
+	warm := cluster.NewBackend(&store.BackendState{
+		ID:                 "warm",
+		ReadyAt:            &past,
+		RecentCreatesCount: 10,
+	})
+
+	handler := SlowStart(5*time.Minute, 3)(func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+		return backends, nil
+	})
+
+	ctx := context.Background()
+	req := &bbb.Request{Resource: bbb.ResourceCreate}
+
+	res, err := handler(ctx, []*cluster.Backend{warming, warm}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].ID() != "warm" {
+		t.Error("unexpected backends:", res)
+	}
+
+	// All backends are limited
+	res, err = handler(ctx, []*cluster.Backend{warming}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Error("expected unfiltered backends:", res)
+	}
+
+	// Below the limit
+	warming = cluster.NewBackend(&store.BackendState{
+		ID:                 "warming",
+		ReadyAt:            &recent,
+		RecentCreatesCount: 2,
+	})
+	res, err = handler(ctx, []*cluster.Backend{warming, warm}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Error("unexpected backends:", res)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sort"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/b3scale/b3scale/pkg/bbb"
 	"github.com/b3scale/b3scale/pkg/cluster"
 )
@@ -22,14 +24,90 @@ func (b BackendsByLoad) Less(i, j int) bool {
 	return b[i].Stress() < b[j].Stress()
 }
 
-// SortLoad sorts Backends by load
-func SortLoad(next cluster.RouterHandler) cluster.RouterHandler {
-	return func(
-		ctx context.Context,
-		backends []*cluster.Backend,
-		req *bbb.Request,
-	) ([]*cluster.Backend, error) {
-		sort.Sort(BackendsByLoad(backends))
-		return next(ctx, backends, req)
+// backendsByStress sorts backends using a stress strategy.
+// The stress is calculated once for each backend.
+type backendsByStress struct {
+	backends []*cluster.Backend
+	stress   []float64
+}
+
+func newBackendsByStress(
+	backends []*cluster.Backend,
+	strategy cluster.StressStrategy,
+) *backendsByStress {
+	stress := make([]float64, len(backends))
+	for i, be := range backends {
+		stress[i] = strategy.Stress(be)
+	}
+	return &backendsByStress{
+		backends: backends,
+		stress:   stress,
+	}
+}
+
+func (b *backendsByStress) Len() int { return len(b.backends) }
+
+func (b *backendsByStress) Swap(i, j int) {
+	b.backends[i], b.backends[j] = b.backends[j], b.backends[i]
+	b.stress[i], b.stress[j] = b.stress[j], b.stress[i]
+}
+
+func (b *backendsByStress) Less(i, j int) bool {
+	return b.stress[i] < b.stress[j]
+}
+
+// SortLoad sorts Backends by load. The load is calculated
+// using the stress strategy. A frontend may select a
+// different strategy by setting
+//
+//	stress_strategy = "attendees"
+func SortLoad(strategy cluster.StressStrategy) cluster.RouterMiddleware {
+	if strategy == nil {
+		strategy = cluster.GetStressStrategy(cluster.StressStrategyDefault)
+	}
+	return func(next cluster.RouterHandler) cluster.RouterHandler {
+		return func(
+			ctx context.Context,
+			backends []*cluster.Backend,
+			req *bbb.Request,
+		) ([]*cluster.Backend, error) {
+			s := frontendStressStrategy(ctx, strategy)
+			before := append([]*cluster.Backend{}, backends...)
+			byStress := newBackendsByStress(backends, s)
+			sort.Stable(byStress)
+			for i, be := range byStress.backends {
+				cluster.TraceStress(ctx, be, byStress.stress[i])
+			}
+			cluster.TraceOrder(ctx, "sort_load", before, backends,
+				"sorted by stress")
+			return next(ctx, backends, req)
+		}
+	}
+}
+
+// frontendStressStrategy selects the stress strategy
+// configured for the frontend. If the frontend does not
+// configure a strategy or the strategy is unknown, the
+// fallback is used.
+func frontendStressStrategy(
+	ctx context.Context,
+	fallback cluster.StressStrategy,
+) cluster.StressStrategy {
+	frontend := cluster.FrontendFromContext(ctx)
+	if frontend == nil {
+		return fallback
+	}
+	name := frontend.Settings().StressStrategy
+	if name == "" {
+		return fallback
+	}
+	strategy := cluster.GetStressStrategy(name)
+	if strategy == nil {
+		log.Warn().
+			Str("frontend", frontend.Key()).
+			Str("strategy", name).
+			Msg("unknown stress strategy, using default")
+		return fallback
 	}
+	return strategy
 }
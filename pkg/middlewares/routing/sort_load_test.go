@@ -1,9 +1,11 @@
 package routing
 
 import (
+	"context"
 	"sort"
 	"testing"
 
+	"github.com/b3scale/b3scale/pkg/bbb"
 	"github.com/b3scale/b3scale/pkg/cluster"
 	"github.com/b3scale/b3scale/pkg/store"
 )
@@ -44,3 +46,53 @@ func TestSortBackendByLoad(t *testing.T) {
 	}
 
 }
+
+func TestSortLoadFrontendStrategy(t *testing.T) {
+	b := []*cluster.Backend{
+		cluster.NewBackend(&store.BackendState{
+			ID:             "A",
+			MeetingsCount:  1,
+			LoadFactor:     1,
+			AttendeesCount: 100,
+		}),
+		cluster.NewBackend(&store.BackendState{
+			ID:             "B",
+			MeetingsCount:  10,
+			LoadFactor:     1,
+			AttendeesCount: 20,
+		}),
+	}
+
+	fe := cluster.NewFrontend(&store.FrontendState{
+		Settings: store.FrontendSettings{
+			StressStrategy: cluster.StressStrategyLeastMeetings,
+		},
+	})
+	ctx := cluster.ContextWithFrontend(context.Background(), fe)
+	req := &bbb.Request{Resource: bbb.ResourceCreate}
+
+	handler := SortLoad(nil)(func(
+		ctx context.Context,
+		backends []*cluster.Backend,
+		req *bbb.Request,
+	) ([]*cluster.Backend, error) {
+		return backends, nil
+	})
+
+	res, err := handler(ctx, b, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].ID() != "A" {
+		t.Error("unexpected:", res[0])
+	}
+
+	// Without frontend the default strategy is used
+	res, err = handler(context.Background(), b, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].ID() != "B" {
+		t.Error("unexpected:", res[0])
+	}
+}
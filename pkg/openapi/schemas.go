@@ -65,6 +65,10 @@ func propFromSliceType(ftype reflect.Type) FieldProperty {
 		itemProps = FieldProperty{
 			"type": "string",
 		}
+	case reflect.Int, reflect.Int64:
+		itemProps = FieldProperty{
+			"type": "integer",
+		}
 	case reflect.Struct:
 		itemProps = FieldProperty{
 			"$ref": "#/components/schemas/" + elem.Name(),
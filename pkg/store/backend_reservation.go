@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ReservationTTL is the time after which a reservation
+// is no longer considered, even if it was not released.
+const ReservationTTL = 1 * time.Minute
+
+// Reserve will add a reservation for a meeting
+// on the backend. This accounts for meetings which are
+// about to be created, but are not yet counted.
+func (s *BackendState) Reserve(
+	ctx context.Context,
+	tx pgx.Tx,
+	meetingID string,
+) error {
+	qry := `
+		INSERT INTO backend_reservations (
+			backend_id,
+			meeting_id,
+			created_at
+		)
+		VALUES ($1, $2, $3)
+	`
+	_, err := tx.Exec(ctx, qry, s.ID, meetingID, time.Now().UTC())
+	return err
+}
+
+// ReleaseReservation removes the reservations for
+// a meeting on the backend.
+func (s *BackendState) ReleaseReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	meetingID string,
+) error {
+	qry := `
+		DELETE FROM backend_reservations
+		 WHERE backend_id = $1
+		   AND meeting_id = $2
+	`
+	_, err := tx.Exec(ctx, qry, s.ID, meetingID)
+	return err
+}
+
+// ClearReservations removes all reservations for the
+// backend, which were created before a point in time.
+func (s *BackendState) ClearReservations(
+	ctx context.Context,
+	tx pgx.Tx,
+	before time.Time,
+) (int64, error) {
+	qry := `
+		DELETE FROM backend_reservations
+		 WHERE backend_id = $1
+		   AND created_at < $2
+	`
+	cmd, err := tx.Exec(ctx, qry, s.ID, before)
+	if err != nil {
+		return 0, err
+	}
+	return cmd.RowsAffected(), nil
+}
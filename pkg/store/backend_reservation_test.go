@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackendStateReserve(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state := backendStateFactory()
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := state.Reserve(ctx, tx, "meeting1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Reserve(ctx, tx, "meeting2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Refresh(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.ReservationsCount != 2 {
+		t.Error("unexpected reservations count:", state.ReservationsCount)
+	}
+
+	if err := state.ReleaseReservation(ctx, tx, "meeting1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Refresh(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.ReservationsCount != 1 {
+		t.Error("unexpected reservations count:", state.ReservationsCount)
+	}
+}
+
+func TestBackendStateClearReservations(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state := backendStateFactory()
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Reserve(ctx, tx, "meeting1"); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := state.ClearReservations(
+		ctx, tx, time.Now().UTC().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Error("unexpected count:", count)
+	}
+}
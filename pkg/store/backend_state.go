@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,18 @@ var (
 	ErrFrontendRequired = errors.New("meeting requires a frontend state")
 )
 
+// NodeDeadTimeout is the duration after which a failing
+// node is considered dead.
+const NodeDeadTimeout = 1 * time.Minute
+
+// LatencyHistorySize is the number of latency
+// measurements kept for a backend.
+const LatencyHistorySize = 5
+
+// RecentCreatesWindow is the duration in which new
+// meetings on a backend are counted as recent creates.
+const RecentCreatesWindow = 1 * time.Minute
+
 // The BackendState is shared across b3scale instances
 // and encapsulates the list of meetings and recordings.
 // The backend.ID should be used as identifier.
@@ -32,9 +45,19 @@ type BackendState struct {
 
 	LastError *string `json:"last_error" doc:"The last error that happend. For example destination host not reachable."`
 
-	Latency        time.Duration `json:"latency" doc:"The amount of milliseconds when polling the current node state."`
-	MeetingsCount  uint          `json:"meetings_count" doc:"Number of meetings on the backend."`
-	AttendeesCount uint          `json:"attendees_count" doc:"Number of participants in meetings on the backend."`
+	Circuit CircuitBreaker `json:"circuit"`
+
+	Latency        time.Duration   `json:"latency" doc:"The amount of milliseconds when polling the current node state."`
+	LatencyHistory []time.Duration `json:"latency_history" doc:"The latencies of the last node syncs."`
+	MeetingsCount  uint            `json:"meetings_count" doc:"Number of meetings on the backend."`
+	AttendeesCount uint            `json:"attendees_count" doc:"Number of participants in meetings on the backend."`
+
+	VideoStreamsCount uint `json:"video_streams_count" doc:"Number of video streams in meetings on the backend."`
+	ReservationsCount uint `json:"reservations_count" doc:"Number of meetings about to be created on the backend."`
+
+	RecentCreatesCount uint `json:"recent_creates_count" doc:"Number of meetings created on the backend within the last minute."`
+
+	ReadyAt *time.Time `json:"ready_at" doc:"The time the backend became ready. This is updated when the backend is enabled or recovers from an error."`
 
 	LoadFactor float64 `json:"load_factor" doc:"The load factor influences the probability of selecting this backend when a meeting is created. The amount of meetings and attendees on the node will be multiplied with the load factor, when calculating the backend stress."`
 
@@ -86,15 +109,31 @@ func GetBackendStates(
 		"backends.agent_ref",
 		"backends.last_error",
 		"backends.latency",
+		"backends.latency_history",
 		"backends.meetings_count",
 		"backends.attendees_count",
+		"backends.video_streams_count",
 		"backends.load_factor",
 		"backends.host",
 		"backends.secret",
 		"backends.settings",
+		"backends.circuit",
+		"backends.ready_at",
 		"backends.created_at",
 		"backends.updated_at",
 		"backends.synced_at").
+		Column(sq.Expr(`(
+			SELECT COUNT(*) FROM backend_reservations
+			 WHERE backend_reservations.backend_id = backends.id
+			   AND backend_reservations.created_at >= ?
+		) AS reservations_count`,
+			time.Now().UTC().Add(-ReservationTTL))).
+		Column(sq.Expr(`(
+			SELECT COUNT(*) FROM meetings
+			 WHERE meetings.backend_id = backends.id
+			   AND meetings.created_at >= ?
+		) AS recent_creates_count`,
+			time.Now().UTC().Add(-RecentCreatesWindow))).
 		ToSql()
 	// log.Println("SQL:", qry, params)
 	rows, err := tx.Query(ctx, qry, params...)
@@ -114,15 +153,21 @@ func GetBackendStates(
 			&state.AgentRef,
 			&state.LastError,
 			&state.Latency,
+			&state.LatencyHistory,
 			&state.MeetingsCount,
 			&state.AttendeesCount,
+			&state.VideoStreamsCount,
 			&state.LoadFactor,
 			&state.Backend.Host,
 			&state.Backend.Secret,
 			&state.Settings,
+			&state.Circuit,
+			&state.ReadyAt,
 			&state.CreatedAt,
 			&state.UpdatedAt,
-			&state.SyncedAt)
+			&state.SyncedAt,
+			&state.ReservationsCount,
+			&state.RecentCreatesCount)
 		if err != nil {
 			return nil, err
 		}
@@ -238,6 +283,7 @@ func (s *BackendState) update(
 			   last_error   = $4,
 
 			   latency      = $5,
+			   latency_history = $12,
 
 			   host         = $6,
 			   secret       = $7,
@@ -247,7 +293,14 @@ func (s *BackendState) update(
 			   load_factor  = $9,
 
 			   synced_at    = $10,
-			   updated_at   = $11
+			   updated_at   = $11,
+
+			   ready_at     = CASE
+			     WHEN $2 = 'ready' AND $3 = 'ready'
+			      AND (node_state <> 'ready' OR admin_state <> 'ready')
+			     THEN $11
+			     ELSE ready_at
+			   END
 
 		 WHERE id = $1
 	`
@@ -265,7 +318,8 @@ func (s *BackendState) update(
 		s.Settings,
 		s.LoadFactor,
 		s.SyncedAt,
-		time.Now().UTC())
+		time.Now().UTC(),
+		s.LatencyHistory)
 
 	return err
 }
@@ -319,6 +373,25 @@ func (s *BackendState) UpdateAgentHeartbeat(
 	return heartbeat, nil
 }
 
+// SetLastError will update the last error of the backend
+// without changing the node state.
+func (s *BackendState) SetLastError(
+	ctx context.Context,
+	tx pgx.Tx,
+	errMsg string,
+) error {
+	qry := `
+		UPDATE backends
+		   SET last_error = $2
+		 WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, qry, s.ID, errMsg); err != nil {
+		return err
+	}
+	s.LastError = &errMsg
+	return nil
+}
+
 // IsAgentAlive checks if the heartbeat is older
 // than the threshold
 func (s *BackendState) IsAgentAlive() bool {
@@ -333,6 +406,56 @@ func (s *BackendState) IsNodeReady() bool {
 	return s.IsAgentAlive() && s.NodeState == "ready"
 }
 
+// AddLatency records a latency measurement and
+// keeps the last LatencyHistorySize measurements.
+func (s *BackendState) AddLatency(latency time.Duration) {
+	s.Latency = latency
+	history := append(s.LatencyHistory, latency)
+	if len(history) > LatencyHistorySize {
+		history = history[len(history)-LatencyHistorySize:]
+	}
+	s.LatencyHistory = history
+}
+
+// MedianLatency is the median of the latency history.
+// Without history, the last measured latency is used.
+func (s *BackendState) MedianLatency() time.Duration {
+	if len(s.LatencyHistory) == 0 {
+		return s.Latency
+	}
+	sorted := make([]time.Duration, len(s.LatencyHistory))
+	copy(sorted, s.LatencyHistory)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// IsWarmingUp checks if the backend became ready
+// within the warm-up period.
+func (s *BackendState) IsWarmingUp(warmup time.Duration) bool {
+	if s.ReadyAt == nil {
+		return false
+	}
+	return time.Now().UTC().Sub(*s.ReadyAt) < warmup
+}
+
+// IsNodeDead checks if the agent did not send a heartbeat
+// or the node was in error for longer than the NodeDeadTimeout.
+// Meetings on a dead node can be recovered on another backend.
+func (s *BackendState) IsNodeDead() bool {
+	now := time.Now().UTC()
+	if now.Sub(s.AgentHeartbeat) > NodeDeadTimeout {
+		return true
+	}
+	return s.NodeState == "error" &&
+		now.Sub(s.SyncedAt) > NodeDeadTimeout
+}
+
 // ClearMeetings will remove all meetings in the current state
 func (s *BackendState) ClearMeetings(
 	ctx context.Context,
@@ -349,8 +472,8 @@ func (s *BackendState) ClearMeetings(
 	return err
 }
 
-// Internal: updateBackendStatCounters counts meetings
-// and attendees for a given backendID
+// Internal: updateBackendStatCounters counts meetings,
+// attendees and video streams for a given backendID
 func updateBackendStatCounters(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -363,20 +486,25 @@ func updateBackendStatCounters(
 		return err
 	}
 
-	// Meeting and attendees counter
+	// Meeting, attendees and video streams counter
 	mcount := len(mstates)
 	acount := 0
+	vcount := 0
 	for _, m := range mstates {
 		acount += len(m.Meeting.Attendees)
+		vcount += m.Meeting.VideoCount
 	}
 
 	qry := `
 		UPDATE backends
 		   SET meetings_count = $2,
-		       attendees_count = $3
+		       attendees_count = $3,
+		       video_streams_count = $4
 		 WHERE backends.id = $1
 	`
-	if _, err := tx.Exec(ctx, qry, backendID, mcount, acount); err != nil {
+	if _, err := tx.Exec(
+		ctx, qry, backendID, mcount, acount, vcount,
+	); err != nil {
 		return err
 	}
 
@@ -7,6 +7,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -172,3 +173,112 @@ func TestBackendStateValidate(t *testing.T) {
 	t.Log(err)
 
 }
+
+func TestBackendStateSetLastError(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state := backendStateFactory()
+	state.NodeState = "ready"
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := state.SetLastError(ctx, tx, "create failed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Refresh(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.NodeState != "ready" {
+		t.Error("unexpected node state:", state.NodeState)
+	}
+	if *state.LastError != "create failed" {
+		t.Error("unexpected last error:", *state.LastError)
+	}
+}
+
+func TestBackendStateIsNodeDead(t *testing.T) {
+	now := time.Now().UTC()
+	state := &BackendState{
+		NodeState:      "ready",
+		AgentHeartbeat: now,
+		SyncedAt:       now,
+	}
+	if state.IsNodeDead() {
+		t.Error("ready node should not be dead")
+	}
+
+	state.NodeState = "error"
+	if state.IsNodeDead() {
+		t.Error("node should not be dead right after an error")
+	}
+
+	state.SyncedAt = now.Add(-2 * NodeDeadTimeout)
+	if !state.IsNodeDead() {
+		t.Error("node in error without sync should be dead")
+	}
+
+	state.NodeState = "ready"
+	state.SyncedAt = now
+	state.AgentHeartbeat = now.Add(-2 * NodeDeadTimeout)
+	if !state.IsNodeDead() {
+		t.Error("node without agent heartbeat should be dead")
+	}
+}
+
+func TestBackendStateReadyAt(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state := backendStateFactory()
+	state.NodeState = "init"
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.ReadyAt != nil {
+		t.Error("unexpected ready at:", state.ReadyAt)
+	}
+
+	state.NodeState = "ready"
+	state.AdminState = "ready"
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.ReadyAt == nil {
+		t.Fatal("expected ready at")
+	}
+	readyAt := *state.ReadyAt
+
+	// Saving again should not reset the time
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if !state.ReadyAt.Equal(readyAt) {
+		t.Error("ready at should not change:", state.ReadyAt)
+	}
+}
+
+func TestBackendStateMedianLatency(t *testing.T) {
+	state := &BackendState{}
+	for i := 1; i <= 7; i++ {
+		state.AddLatency(time.Duration(i) * time.Millisecond)
+	}
+	if len(state.LatencyHistory) != LatencyHistorySize {
+		t.Error("unexpected history:", state.LatencyHistory)
+	}
+	if state.Latency != 7*time.Millisecond {
+		t.Error("unexpected latency:", state.Latency)
+	}
+	if state.MedianLatency() != 5*time.Millisecond {
+		t.Error("unexpected median:", state.MedianLatency())
+	}
+
+	// A single slow measurement does not dominate
+	state.AddLatency(time.Second)
+	if state.MedianLatency() != 6*time.Millisecond {
+		t.Error("unexpected median:", state.MedianLatency())
+	}
+}
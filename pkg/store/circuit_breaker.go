@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// Circuit breaker parameters
+const (
+	// CircuitWindow is the duration in which requests
+	// and errors are counted.
+	CircuitWindow = 1 * time.Minute
+
+	// CircuitMinRequests is the number of requests within
+	// the window required before the circuit can open.
+	CircuitMinRequests = 5
+
+	// CircuitErrorRate is the ratio of errors to requests
+	// within the window which will open the circuit.
+	CircuitErrorRate = 0.5
+
+	// CircuitCooldown is the duration the circuit stays
+	// open, before a probe request is allowed.
+	CircuitCooldown = 30 * time.Second
+
+	// CircuitProbeTimeout is the duration after which
+	// another probe is allowed, if the result of the
+	// previous probe was not recorded.
+	CircuitProbeTimeout = 10 * time.Second
+)
+
+// A CircuitBreaker tracks the request errors of a backend.
+// When the error rate exceeds a threshold, the circuit
+// opens and the backend will not receive new requests.
+// After a cooldown the circuit is half open: A single
+// probe request will either close or open the circuit again.
+type CircuitBreaker struct {
+	State       string    `json:"state" doc:"The state of the circuit breaker. When open, the backend is not considered for new requests." enum:"closed,open,half_open"`
+	Requests    uint      `json:"requests" doc:"Number of requests in the current window."`
+	Errors      uint      `json:"errors" doc:"Number of failed requests in the current window."`
+	WindowStart time.Time `json:"window_start" doc:"The start of the current window."`
+	OpenedAt    time.Time `json:"opened_at" doc:"The last time the circuit was opened."`
+	ProbeAt     time.Time `json:"probe_at" doc:"The last time a probe request was sent to the backend while the circuit was half open."`
+}
+
+// CurrentState gets the state of the circuit breaker
+// at a point in time. An open circuit will be half open
+// after the cooldown.
+func (c *CircuitBreaker) CurrentState(now time.Time) string {
+	switch c.State {
+	case CircuitOpen:
+		if now.Sub(c.OpenedAt) >= CircuitCooldown {
+			return CircuitHalfOpen
+		}
+		return CircuitOpen
+	case CircuitHalfOpen:
+		return CircuitHalfOpen
+	}
+	return CircuitClosed
+}
+
+// Allow checks if requests to the backend are permitted.
+// This is only the case if the circuit is closed.
+func (c *CircuitBreaker) Allow(now time.Time) bool {
+	return c.CurrentState(now) == CircuitClosed
+}
+
+// CanProbe checks if a probe request is permitted: The
+// circuit must be half open and there is no pending probe.
+func (c *CircuitBreaker) CanProbe(now time.Time) bool {
+	if c.CurrentState(now) != CircuitHalfOpen {
+		return false
+	}
+	if c.ProbeAt.Before(c.OpenedAt) {
+		return true
+	}
+	return now.Sub(c.ProbeAt) >= CircuitProbeTimeout
+}
+
+// Record updates the circuit breaker with the
+// result of a request.
+func (c *CircuitBreaker) Record(success bool, now time.Time) {
+	var failures uint
+	if !success {
+		failures = 1
+	}
+	c.RecordResults(1, failures, now)
+}
+
+// RecordResults updates the circuit breaker with
+// the number of requests and failures.
+func (c *CircuitBreaker) RecordResults(requests, failures uint, now time.Time) {
+	if requests == 0 {
+		return
+	}
+	if now.Sub(c.WindowStart) > CircuitWindow {
+		c.Requests = 0
+		c.Errors = 0
+		c.WindowStart = now
+	}
+
+	switch c.CurrentState(now) {
+	case CircuitOpen:
+		// Requests which were started before the
+		// circuit opened are ignored.
+		return
+	case CircuitHalfOpen:
+		// The probe decides the next state
+		c.Requests = 0
+		c.Errors = 0
+		c.WindowStart = now
+		if failures == 0 {
+			c.State = CircuitClosed
+		} else {
+			c.State = CircuitOpen
+			c.OpenedAt = now
+		}
+		return
+	}
+
+	c.State = CircuitClosed
+	c.Requests += requests
+	c.Errors += failures
+	if c.Requests < CircuitMinRequests {
+		return
+	}
+	rate := float64(c.Errors) / float64(c.Requests)
+	if rate >= CircuitErrorRate {
+		c.State = CircuitOpen
+		c.OpenedAt = now
+	}
+}
+
+// RecordRequests updates the circuit breaker of the
+// backend with the results of requests. The circuit breaker
+// is shared across all instances, so the row is locked
+// while updating. The results should be aggregated
+// to keep the lock contention low.
+func (s *BackendState) RecordRequests(
+	ctx context.Context,
+	tx pgx.Tx,
+	requests uint,
+	failures uint,
+) error {
+	circuit, err := s.lockCircuit(ctx, tx)
+	if err != nil {
+		return err
+	}
+	circuit.RecordResults(requests, failures, time.Now().UTC())
+	return s.updateCircuit(ctx, tx, circuit)
+}
+
+// ClaimCircuitProbe checks if a probe request may be sent
+// to the backend with a half open circuit. Only one
+// instance will succeed in claiming the probe.
+func (s *BackendState) ClaimCircuitProbe(
+	ctx context.Context,
+	tx pgx.Tx,
+) (bool, error) {
+	circuit, err := s.lockCircuit(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	if !circuit.CanProbe(now) {
+		s.Circuit = circuit
+		return false, nil
+	}
+	circuit.ProbeAt = now
+	if err := s.updateCircuit(ctx, tx, circuit); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// lockCircuit selects the circuit for update
+func (s *BackendState) lockCircuit(
+	ctx context.Context,
+	tx pgx.Tx,
+) (CircuitBreaker, error) {
+	qry := `
+		SELECT circuit FROM backends
+		 WHERE id = $1
+		   FOR UPDATE
+	`
+	circuit := CircuitBreaker{}
+	err := tx.QueryRow(ctx, qry, s.ID).Scan(&circuit)
+	return circuit, err
+}
+
+// updateCircuit writes the circuit to the backend
+func (s *BackendState) updateCircuit(
+	ctx context.Context,
+	tx pgx.Tx,
+	circuit CircuitBreaker,
+) error {
+	qry := `
+		UPDATE backends
+		   SET circuit = $2
+		 WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, qry, s.ID, circuit); err != nil {
+		return err
+	}
+	s.Circuit = circuit
+	return nil
+}
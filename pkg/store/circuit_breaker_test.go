@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerRecord(t *testing.T) {
+	now := time.Now().UTC()
+	c := &CircuitBreaker{}
+
+	// Not enough requests to open the circuit
+	for i := 0; i < CircuitMinRequests-1; i++ {
+		c.Record(false, now)
+	}
+	if c.CurrentState(now) != CircuitClosed {
+		t.Error("unexpected state:", c.CurrentState(now))
+	}
+
+	c.Record(false, now)
+	if c.CurrentState(now) != CircuitOpen {
+		t.Error("unexpected state:", c.CurrentState(now))
+	}
+	if c.Allow(now) {
+		t.Error("open circuit should not allow requests")
+	}
+
+	// After the cooldown the circuit is half open
+	later := now.Add(CircuitCooldown)
+	if c.CurrentState(later) != CircuitHalfOpen {
+		t.Error("unexpected state:", c.CurrentState(later))
+	}
+	if c.Allow(later) {
+		t.Error("half open circuit should only allow a probe")
+	}
+	if !c.CanProbe(later) {
+		t.Error("half open circuit should allow a probe")
+	}
+	c.ProbeAt = later
+	if c.CanProbe(later) {
+		t.Error("only a single probe should be allowed")
+	}
+	if !c.CanProbe(later.Add(CircuitProbeTimeout)) {
+		t.Error("a probe should be allowed after the probe timeout")
+	}
+
+	// A failure opens the circuit again
+	c.Record(false, later)
+	if c.CurrentState(later) != CircuitOpen {
+		t.Error("unexpected state:", c.CurrentState(later))
+	}
+
+	// A success closes the circuit
+	later = later.Add(CircuitCooldown)
+	c.Record(true, later)
+	if c.CurrentState(later) != CircuitClosed {
+		t.Error("unexpected state:", c.CurrentState(later))
+	}
+	if c.Requests != 0 || c.Errors != 0 {
+		t.Error("counters should be reset:", c)
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	now := time.Now().UTC()
+	c := &CircuitBreaker{}
+	c.Record(true, now)
+	c.Record(false, now)
+	c.Record(false, now)
+
+	// Errors from an old window are not considered
+	later := now.Add(CircuitWindow + time.Second)
+	c.Record(false, later)
+	if c.Requests != 1 || c.Errors != 1 {
+		t.Error("unexpected counters:", c)
+	}
+	if c.CurrentState(later) != CircuitClosed {
+		t.Error("unexpected state:", c.CurrentState(later))
+	}
+}
+
+func TestBackendStateRecordRequest(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state := backendStateFactory()
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := state.RecordRequests(
+		ctx, tx, CircuitMinRequests, CircuitMinRequests); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Refresh(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.Circuit.State != CircuitOpen {
+		t.Error("unexpected state:", state.Circuit.State)
+	}
+
+	// The probe can not be claimed during the cooldown
+	claimed, err := state.ClaimCircuitProbe(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed {
+		t.Error("probe should not be claimed while the circuit is open")
+	}
+}
+
+func TestCircuitBreakerRecordResults(t *testing.T) {
+	now := time.Now().UTC()
+	c := &CircuitBreaker{}
+	c.RecordResults(4, 1, now)
+	if c.CurrentState(now) != CircuitClosed {
+		t.Error("unexpected state:", c.CurrentState(now))
+	}
+	c.RecordResults(4, 4, now)
+	if c.CurrentState(now) != CircuitOpen {
+		t.Error("unexpected state:", c.CurrentState(now))
+	}
+}
@@ -11,6 +11,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -21,6 +22,59 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// Errors
+var (
+	// ErrCommandNotFailed is returned when a command
+	// should be requeued, which did not fail.
+	ErrCommandNotFailed = errors.New("command not found or not failed")
+)
+
+// Command states
+const (
+	CommandRequested = "requested"
+	CommandSuccess   = "success"
+	CommandError     = "error"
+	CommandFailed    = "failed"
+)
+
+const (
+	// DefaultCommandMaxAttempts is the number of attempts
+	// for commands which should survive a short outage
+	// of a node.
+	DefaultCommandMaxAttempts = 5
+
+	// CommandMaxAttemptsLimit is the maximum number
+	// of attempts of a command created through the API.
+	CommandMaxAttemptsLimit = 20
+
+	// CommandRetryBackoff is the delay before the first
+	// retry of a command. It is doubled with each attempt.
+	CommandRetryBackoff = 5 * time.Second
+
+	// CommandRetryBackoffMax limits the delay
+	// between two attempts.
+	CommandRetryBackoffMax = 5 * time.Minute
+)
+
+// Command priorities: Commands with a higher priority
+// are processed first.
+const (
+	CommandPriorityLow    = -10
+	CommandPriorityNormal = 0
+	CommandPriorityHigh   = 10
+)
+
+const (
+	// DefaultCommandPollInterval limits polling
+	// the queue to 15 Hz.
+	DefaultCommandPollInterval = 66 * time.Millisecond
+
+	// DefaultCommandMaxInFlight limits the number of
+	// commands processed at the same time by an instance.
+	// Each command holds two connections of the pool.
+	DefaultCommandMaxInFlight = 32
+)
+
 // CommandHandler is a callback function for handling
 // commands. The command was successful if no error was
 // returned.
@@ -31,11 +85,16 @@ type Command struct {
 	ID  string `json:"id"`
 	Seq int    `json:"seq"`
 
-	State string `json:"state" doc:"The current state of the command." enum:"requested,success,error"`
+	State string `json:"state" doc:"The current state of the command. A command is failed, when all attempts were exhausted or it expired before it was run. Failed commands can be requeued." enum:"requested,success,error,failed"`
+
+	Action   string      `json:"action" doc:"The operation to perform." enum:"end_all_meetings,end_frontend_meetings"`
+	Priority int         `json:"priority" doc:"Commands with a higher priority are processed first. Must be between -10 and 10. Defaults to 10 for commands created through the API."`
+	Params   interface{} `json:"params" doc:"Key value options for the command. See example above."`
+	Result   interface{} `json:"result" doc:"The result of the command. as key value object."`
 
-	Action string      `json:"action" doc:"The operation to perform." enum:"end_all_meetings"`
-	Params interface{} `json:"params" doc:"Key value options for the command. See example above."`
-	Result interface{} `json:"result" doc:"The result of the command. as key value object."`
+	Attempts    int        `json:"attempts" doc:"The number of times the command was run."`
+	MaxAttempts int        `json:"max_attempts" doc:"A command is retried with an exponential backoff until the maximum attempts are exhausted. Defaults to 1 for internal commands and 5 for commands created through the API, where 0 also selects the default. At most 20 attempts are allowed through the API."`
+	NotBefore   *time.Time `json:"not_before" doc:"The next attempt of the command is not run before this time."`
 
 	Deadline  time.Time  `json:"deadline" doc:"The commands need to be processed before the deadline is reached. The deadline is optional."`
 	StartedAt *time.Time `json:"started_at"`
@@ -57,11 +116,52 @@ func (cmd *Command) FetchParams(
 // The CommandQueue is connected to the database and
 // provides methods for queuing and dequeuing commands.
 type CommandQueue struct {
+	limits map[string]int
+
+	pollInterval time.Duration
+	inFlight     chan struct{}
 }
 
 // NewCommandQueue initializes a new command queue
 func NewCommandQueue() *CommandQueue {
-	return &CommandQueue{}
+	return &CommandQueue{
+		limits:       map[string]int{},
+		pollInterval: DefaultCommandPollInterval,
+		inFlight:     make(chan struct{}, DefaultCommandMaxInFlight),
+	}
+}
+
+// SetPollInterval sets the interval in which the queue
+// is checked for the next command. The interval must be
+// set before receiving commands.
+func (q *CommandQueue) SetPollInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCommandPollInterval
+	}
+	q.pollInterval = interval
+}
+
+// SetMaxInFlight limits the number of commands processed
+// at the same time. It should be well below the size of
+// the connection pool. The limit must be set before
+// receiving commands.
+func (q *CommandQueue) SetMaxInFlight(max int) {
+	if max <= 0 {
+		max = DefaultCommandMaxInFlight
+	}
+	q.inFlight = make(chan struct{}, max)
+}
+
+// SetConcurrencyLimit limits the number of commands of
+// an action, which are processed at the same time across
+// all instances. A limit of 0 means unlimited.
+// The limits must be set before receiving commands.
+func (q *CommandQueue) SetConcurrencyLimit(action string, limit int) {
+	if limit <= 0 {
+		delete(q.limits, action)
+		return
+	}
+	q.limits[action] = limit
 }
 
 // QueueCommand adds a new command to the queue
@@ -74,18 +174,25 @@ func QueueCommand(ctx context.Context, tx pgx.Tx, cmd *Command) error {
 		return err
 	}
 
+	if cmd.MaxAttempts < 1 {
+		cmd.MaxAttempts = 1
+	}
+
 	// Add command to queue and notify instances
 	qry := `
 	  INSERT INTO commands (
 	  	action,
 		params,
-		deadline
+		deadline,
+		max_attempts,
+		priority
 	  ) VALUES (
-		$1, $2, $3
+		$1, $2, $3, $4, $5
 	  )
 	  RETURNING id`
 	var cmdID string
-	err = tx.QueryRow(ctx, qry, cmd.Action, params, deadline).
+	err = tx.QueryRow(ctx, qry,
+		cmd.Action, params, deadline, cmd.MaxAttempts, cmd.Priority).
 		Scan(&cmdID)
 	if err != nil {
 		return err
@@ -93,27 +200,93 @@ func QueueCommand(ctx context.Context, tx pgx.Tx, cmd *Command) error {
 
 	// Update command
 	cmd.ID = cmdID
+	cmd.State = CommandRequested
 	cmd.CreatedAt = time.Now().UTC()
 	return nil
 }
 
+// RequeueCommand resets a failed command, so it is
+// run again with all attempts.
+func RequeueCommand(ctx context.Context, tx pgx.Tx, id string) error {
+	deadline := time.Now().UTC().Add(120 * time.Second)
+	qry := `
+		UPDATE commands
+		   SET state      = 'requested',
+		       attempts   = 0,
+		       not_before = NULL,
+		       result     = NULL,
+		       started_at = NULL,
+		       stopped_at = NULL,
+		       deadline   = $2
+		 WHERE id = $1
+		   AND state IN ('failed', 'error')`
+	tag, err := tx.Exec(ctx, qry, id, deadline)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCommandNotFailed
+	}
+	// Notify instances about the requeued command
+	_, err = tx.Exec(ctx, "NOTIFY commands_queue")
+	return err
+}
+
+// CommandBackoff calculates the delay before the next
+// attempt of a command after a number of attempts.
+func CommandBackoff(attempts int) time.Duration {
+	backoff := CommandRetryBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= CommandRetryBackoffMax {
+			return CommandRetryBackoffMax
+		}
+	}
+	return backoff
+}
+
 // Receive will await a command and will block
-// until a command can be processed. If the handler
-// responds with an error, the error will be returned.
+// until a command can be processed. An error is only
+// returned if waiting for commands fails.
 func (q *CommandQueue) Receive(handler CommandHandler) error {
+	return q.receive(context.Background(), handler)
+}
+
+// receive checks the queue for the next command in the
+// poll interval until the context is done. The commands
+// are dequeued with SKIP LOCKED, so this works with a
+// transaction pooling connection to the database.
+func (q *CommandQueue) receive(
+	ctx context.Context,
+	handler CommandHandler,
+) error {
 	for {
-		// Start processing in the background, so we can take
-		// care of the next incomming command.
-		go func(q *CommandQueue) {
-			err := q.process(handler)
-			if err != nil {
-				log.Error().Err(err).Msg("processing job failed")
-			}
-		}(q)
+		q.dispatch(handler)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
 
-		// Limit polling frequency to 15 Hz
-		time.Sleep(66 * time.Millisecond)
+// dispatch starts processing in the background, so we
+// can take care of the next incomming command. When the
+// maximum number of commands is in flight, the queue
+// is checked again in the next interval.
+func (q *CommandQueue) dispatch(handler CommandHandler) {
+	select {
+	case q.inFlight <- struct{}{}:
+	default:
+		return // all slots are busy
 	}
+	go func() {
+		defer func() { <-q.inFlight }()
+		if err := q.process(handler); err != nil {
+			log.Error().Err(err).Msg("processing job failed")
+		}
+	}()
 }
 
 // Run the handler, but recover if an error occured.
@@ -175,8 +348,12 @@ func GetCommands(
 		"seq",
 		"state",
 		"action",
+		"priority",
 		"params",
 		"result",
+		"attempts",
+		"max_attempts",
+		"not_before",
 		"deadline",
 		"created_at",
 		"started_at",
@@ -198,8 +375,12 @@ func GetCommands(
 			&cmd.Seq,
 			&cmd.State,
 			&cmd.Action,
+			&cmd.Priority,
 			&cmd.Params,
 			&cmd.Result,
+			&cmd.Attempts,
+			&cmd.MaxAttempts,
+			&cmd.NotBefore,
 			&cmd.Deadline,
 			&cmd.CreatedAt,
 			&cmd.StartedAt,
@@ -232,52 +413,41 @@ func (q *CommandQueue) process(handler CommandHandler) error {
 
 	// We dequeue and fetch a command within a transaction.
 	// During handling the command will be locked.
-	qry := `
-		SELECT 
-			id,
-			seq,
-			action,
-			deadline,
-			created_at
-		  FROM commands
-		 WHERE state = 'requested'
-		 ORDER BY seq ASC
-		 LIMIT 1
-		   FOR UPDATE SKIP LOCKED`
-
-	// Select command
-	cmd := &Command{}
-	err = tx.QueryRow(ctx, qry).Scan(
-		&cmd.ID,
-		&cmd.Seq,
-		&cmd.Action,
-		&cmd.Deadline,
-		&cmd.CreatedAt)
-	if err != nil && err == pgx.ErrNoRows {
-		return nil // Ok. There was just nothing to do.
-	} else if err != nil {
+	cmd, err := q.dequeue(ctx, tx)
+	if err != nil {
 		return err
 	}
+	if cmd == nil {
+		return nil // Ok. There was just nothing to do.
+	}
 
 	cmd.tx = tx
 
-	// Check deadline
-	state := "success"
-	var result interface{}
-	if cmd.Deadline.Before(time.Now().UTC()) {
+	// Check deadline. The deadline only applies to the
+	// first attempt, retries are limited by the attempts.
+	state := CommandSuccess
+	var (
+		result    interface{}
+		notBefore *time.Time
+	)
+	if cmd.Attempts == 0 && cmd.Deadline.Before(time.Now().UTC()) {
 		// Timeout
-		state = "error"
+		state = CommandFailed
 		result = "timedout"
 	} else {
 		// Apply command handler
+		cmd.Attempts++
 		result, err = safeExecHandler(ctx, cmd, handler)
 		if err != nil {
+			state, notBefore = nextCommandAttempt(cmd, time.Now().UTC())
 			log.Error().
 				Err(err).
 				Int("seq", cmd.Seq).
 				Str("action", cmd.Action).
+				Int("attempt", cmd.Attempts).
+				Int("max_attempts", cmd.MaxAttempts).
+				Str("state", state).
 				Msg("exec command handler error")
-			state = "error"
 			result = fmt.Sprintf("%s", err)
 		}
 	}
@@ -290,19 +460,23 @@ func (q *CommandQueue) process(handler CommandHandler) error {
 	}
 
 	// Write result
-	qry = `
+	qry := `
 		UPDATE commands
 		   SET state      = $2,
 		       result     = $3,
 			   started_at = $4,
-			   stopped_at = $5
+			   stopped_at = $5,
+			   attempts   = $6,
+			   not_before = $7
 
 		 WHERE id = $1`
 	_, err = tx.Exec(ctx, qry, cmd.ID,
 		state,
 		data,
 		startedAt,
-		stoppedAt)
+		stoppedAt,
+		cmd.Attempts,
+		notBefore)
 	if err != nil {
 		return err
 	}
@@ -315,6 +489,110 @@ func (q *CommandQueue) process(handler CommandHandler) error {
 	return nil
 }
 
+// dequeue selects the next command by priority and
+// sequence. Commands waiting for a retry are skipped until
+// the backoff elapsed. When the concurrency limit of an
+// action is reached, commands of other actions are
+// considered. If there is nothing to do, nil is returned.
+func (q *CommandQueue) dequeue(
+	ctx context.Context,
+	tx pgx.Tx,
+) (*Command, error) {
+	qry := `
+		SELECT 
+			id,
+			seq,
+			action,
+			priority,
+			attempts,
+			max_attempts,
+			deadline,
+			created_at
+		  FROM commands
+		 WHERE state = 'requested'
+		   AND (not_before IS NULL OR not_before <= $1)
+		   AND NOT (action = ANY($2))
+		 ORDER BY priority DESC, seq ASC
+		 LIMIT 1
+		   FOR UPDATE SKIP LOCKED`
+
+	saturated := []string{}
+	for {
+		// The command is selected within a savepoint, so the
+		// row lock can be released if the action is saturated.
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cmd := &Command{}
+		err = sp.QueryRow(ctx, qry, time.Now().UTC(), saturated).Scan(
+			&cmd.ID,
+			&cmd.Seq,
+			&cmd.Action,
+			&cmd.Priority,
+			&cmd.Attempts,
+			&cmd.MaxAttempts,
+			&cmd.Deadline,
+			&cmd.CreatedAt)
+		if err == pgx.ErrNoRows {
+			return nil, sp.Rollback(ctx)
+		} else if err != nil {
+			return nil, err
+		}
+
+		acquired, err := q.acquireSlot(ctx, sp, cmd.Action)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return cmd, sp.Commit(ctx)
+		}
+		if err := sp.Rollback(ctx); err != nil {
+			return nil, err
+		}
+		saturated = append(saturated, cmd.Action)
+	}
+}
+
+// acquireSlot tries to get one of the slots of an action
+// limited by the concurrency limit. The slots are advisory
+// locks, released at the end of the command transaction.
+func (q *CommandQueue) acquireSlot(
+	ctx context.Context,
+	tx pgx.Tx,
+	action string,
+) (bool, error) {
+	limit, ok := q.limits[action]
+	if !ok {
+		return true, nil // unlimited
+	}
+	qry := `SELECT pg_try_advisory_xact_lock(hashtext($1))`
+	for slot := 0; slot < limit; slot++ {
+		key := fmt.Sprintf("command:%s:%d", action, slot)
+		acquired := false
+		if err := tx.QueryRow(ctx, qry, key).Scan(&acquired); err != nil {
+			return false, err
+		}
+		if acquired {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// nextCommandAttempt decides if a command is retried
+// after an error, or if it failed.
+func nextCommandAttempt(
+	cmd *Command,
+	now time.Time,
+) (string, *time.Time) {
+	if cmd.Attempts >= cmd.MaxAttempts {
+		return CommandFailed, nil
+	}
+	notBefore := now.Add(CommandBackoff(cmd.Attempts))
+	return CommandRequested, &notBefore
+}
+
 // NextDeadline calculates the deadline for a
 // newly requested command
 func NextDeadline(dt time.Duration) time.Time {
@@ -361,3 +639,9 @@ func CountCommandsSuccess(ctx context.Context, tx pgx.Tx) (int, error) {
 func CountCommandsError(ctx context.Context, tx pgx.Tx) (int, error) {
 	return CountCommandsWithState(ctx, tx, "error")
 }
+
+// CountCommandsFailed returns the number of commands
+// which exhausted all attempts or expired.
+func CountCommandsFailed(ctx context.Context, tx pgx.Tx) (int, error) {
+	return CountCommandsWithState(ctx, tx, CommandFailed)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestSafeExecHandler(t *testing.T) {
@@ -82,3 +83,187 @@ func TestCountCommandsRequested(t *testing.T) {
 	}
 
 }
+
+func TestCommandBackoff(t *testing.T) {
+	if b := CommandBackoff(1); b != CommandRetryBackoff {
+		t.Error("unexpected backoff:", b)
+	}
+	if b := CommandBackoff(3); b != 4*CommandRetryBackoff {
+		t.Error("unexpected backoff:", b)
+	}
+	if b := CommandBackoff(100); b != CommandRetryBackoffMax {
+		t.Error("unexpected backoff:", b)
+	}
+}
+
+func TestNextCommandAttempt(t *testing.T) {
+	now := time.Now().UTC()
+	cmd := &Command{
+		Attempts:    1,
+		MaxAttempts: 3,
+	}
+	state, notBefore := nextCommandAttempt(cmd, now)
+	if state != CommandRequested {
+		t.Error("unexpected state:", state)
+	}
+	if notBefore == nil || !notBefore.Equal(now.Add(CommandRetryBackoff)) {
+		t.Error("unexpected not before:", notBefore)
+	}
+
+	cmd.Attempts = 3
+	state, notBefore = nextCommandAttempt(cmd, now)
+	if state != CommandFailed {
+		t.Error("unexpected state:", state)
+	}
+	if notBefore != nil {
+		t.Error("a failed command should not be retried")
+	}
+}
+
+func TestRequeueCommand(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	cmd := &Command{
+		Action:      "test",
+		MaxAttempts: 2,
+	}
+	if err := QueueCommand(ctx, tx, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	// A requested command can not be requeued
+	if err := RequeueCommand(ctx, tx, cmd.ID); err != ErrCommandNotFailed {
+		t.Error("unexpected error:", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE commands
+		   SET state = 'failed', attempts = 2
+		 WHERE id = $1`, cmd.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequeueCommand(ctx, tx, cmd.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := GetCommand(ctx, tx, Q().Where("id = ?", cmd.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.State != CommandRequested {
+		t.Error("unexpected state:", cmd.State)
+	}
+	if cmd.Attempts != 0 || cmd.MaxAttempts != 2 {
+		t.Error("unexpected attempts:", cmd.Attempts, cmd.MaxAttempts)
+	}
+}
+
+func TestCommandQueueDequeuePriority(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	tx.Exec(ctx, "DELETE FROM commands")
+
+	low := &Command{Action: "low", Priority: CommandPriorityLow}
+	if err := QueueCommand(ctx, tx, low); err != nil {
+		t.Fatal(err)
+	}
+	high := &Command{Action: "high", Priority: CommandPriorityHigh}
+	if err := QueueCommand(ctx, tx, high); err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewCommandQueue()
+	cmd, err := q.dequeue(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd == nil || cmd.ID != high.ID {
+		t.Error("expected the command with the higher priority:", cmd)
+	}
+}
+
+func TestCommandQueueSetPollInterval(t *testing.T) {
+	q := NewCommandQueue()
+	q.SetPollInterval(0)
+	if q.pollInterval != DefaultCommandPollInterval {
+		t.Error("unexpected poll interval:", q.pollInterval)
+	}
+	q.SetPollInterval(time.Second)
+	if q.pollInterval != time.Second {
+		t.Error("unexpected poll interval:", q.pollInterval)
+	}
+}
+
+func TestCommandQueueDispatchMaxInFlight(t *testing.T) {
+	q := NewCommandQueue()
+	q.SetMaxInFlight(1)
+
+	// The only slot is taken, so nothing is dispatched
+	q.inFlight <- struct{}{}
+	q.dispatch(func(ctx context.Context, cmd *Command) (interface{}, error) {
+		t.Error("unexpected command:", cmd)
+		return nil, nil
+	})
+	if len(q.inFlight) != 1 {
+		t.Error("unexpected commands in flight:", len(q.inFlight))
+	}
+}
+
+func TestCommandQueueReceive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q := NewCommandQueue()
+	q.SetPollInterval(10 * time.Millisecond)
+
+	action := "test_receive"
+	defer func() {
+		tx := beginTest(context.Background(), t)
+		tx.Exec(context.Background(),
+			"DELETE FROM commands WHERE action = $1", action)
+		tx.Commit(context.Background())
+	}()
+
+	received := make(chan string, 1)
+	handler := func(ctx context.Context, cmd *Command) (interface{}, error) {
+		if cmd.Action == action {
+			received <- cmd.ID
+		}
+		return true, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.receive(ctx, handler)
+	}()
+
+	// Queue the command while receiving. The
+	// command must be committed to be received.
+	time.Sleep(100 * time.Millisecond)
+	tx := beginTest(ctx, t)
+	cmd := &Command{Action: action}
+	if err := QueueCommand(ctx, tx, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case id := <-received:
+		if id != cmd.ID {
+			t.Error("unexpected command:", id)
+		}
+	case <-ctx.Done():
+		t.Fatal("command was not received")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("unexpected error:", err)
+	}
+}
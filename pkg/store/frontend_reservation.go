@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ReserveFrontendMeeting adds a reservation for a new
+// meeting of the frontend, if the running meetings and
+// reservations are below the limit. Concurrent reservations
+// of the frontend are serialized until the transaction ends.
+// Reservations expire after the ReservationTTL and are
+// removed with the next reservation of the frontend.
+func ReserveFrontendMeeting(
+	ctx context.Context,
+	tx pgx.Tx,
+	frontendID string,
+	meetingID string,
+	max int,
+) (bool, error) {
+	lockQry := `SELECT pg_advisory_xact_lock(hashtext($1))`
+	if _, err := tx.Exec(ctx, lockQry, "frontend:"+frontendID); err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	expireQry := `
+		DELETE FROM frontend_reservations
+		 WHERE frontend_id = $1
+		   AND created_at < $2`
+	if _, err := tx.Exec(
+		ctx, expireQry, frontendID, now.Add(-ReservationTTL),
+	); err != nil {
+		return false, err
+	}
+
+	countQry := `
+		SELECT (SELECT COUNT(*) FROM meetings
+		         WHERE frontend_id = $1
+		           AND parent_id IS NULL)
+		     + (SELECT COUNT(*) FROM frontend_reservations
+		         WHERE frontend_id = $1
+		           AND meeting_id <> $2)`
+	var count int
+	if err := tx.QueryRow(
+		ctx, countQry, frontendID, meetingID,
+	).Scan(&count); err != nil {
+		return false, err
+	}
+	if count >= max {
+		return false, nil
+	}
+
+	insertQry := `
+		INSERT INTO frontend_reservations (
+			frontend_id,
+			meeting_id,
+			created_at
+		)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (frontend_id, meeting_id)
+		DO UPDATE SET created_at = EXCLUDED.created_at`
+	if _, err := tx.Exec(ctx, insertQry, frontendID, meetingID, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseFrontendReservation removes the reservation
+// for a meeting of the frontend.
+func ReleaseFrontendReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	frontendID string,
+	meetingID string,
+) error {
+	qry := `
+		DELETE FROM frontend_reservations
+		 WHERE frontend_id = $1
+		   AND meeting_id = $2
+	`
+	_, err := tx.Exec(ctx, qry, frontendID, meetingID)
+	return err
+}
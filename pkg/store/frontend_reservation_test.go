@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReserveFrontendMeeting(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	frontend := frontendStateFactory()
+	if err := frontend.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := ReserveFrontendMeeting(ctx, tx, frontend.ID, "meeting1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected a reservation for meeting1")
+	}
+	// Reserving the same meeting again is not counted
+	ok, err = ReserveFrontendMeeting(ctx, tx, frontend.ID, "meeting1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected a reservation for meeting1 again")
+	}
+	ok, err = ReserveFrontendMeeting(ctx, tx, frontend.ID, "meeting2", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected a reservation for meeting2")
+	}
+	ok, err = ReserveFrontendMeeting(ctx, tx, frontend.ID, "meeting3", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("the limit should be reached")
+	}
+
+	if err := ReleaseFrontendReservation(
+		ctx, tx, frontend.ID, "meeting1"); err != nil {
+		t.Fatal(err)
+	}
+	ok, err = ReserveFrontendMeeting(ctx, tx, frontend.ID, "meeting3", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected a reservation for meeting3")
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -174,7 +175,9 @@ func (s *FrontendState) Delete(ctx context.Context, tx pgx.Tx) error {
 }
 
 // Validate checks for presence of required fields.
-func (s *FrontendState) Validate() ValidationError {
+// A stress strategy in the settings must be one of
+// the names of the available strategies.
+func (s *FrontendState) Validate(stressStrategies []string) ValidationError {
 	err := ValidationError{}
 
 	if s.Frontend == nil {
@@ -192,6 +195,31 @@ func (s *FrontendState) Validate() ValidationError {
 		err.Add("bbb.secret", ErrFieldRequired)
 	}
 
+	for i, rule := range s.Settings.RoutingRules {
+		field := fmt.Sprintf("settings.routing_rules.%d", i)
+		if rule == nil {
+			err.Add(field, ErrFieldRequired)
+			continue
+		}
+		if _, e := ParseRuleCondition(rule.If); e != nil {
+			err.Add(field+".if", e.Error())
+		}
+		if len(rule.RequireTags) == 0 {
+			err.Add(field+".require_tags", ErrFieldRequired)
+		}
+	}
+
+	if strategy := s.Settings.StressStrategy; strategy != "" {
+		known := false
+		for _, name := range stressStrategies {
+			known = known || name == strategy
+		}
+		if !known {
+			err.Add("settings.stress_strategy", fmt.Sprintf(
+				"must be one of: %s", strings.Join(stressStrategies, ", ")))
+		}
+	}
+
 	if len(err) > 0 {
 		return err
 	}
@@ -224,6 +252,33 @@ func LookupFrontendIDByMeetingID(
 	return frontendID, true, nil
 }
 
+// LookupPeakAttendees queries the frontend_meetings
+// mapping for the highest number of attendees seen in
+// previous runs of a meeting. If the meeting is unknown,
+// the peak is 0.
+func LookupPeakAttendees(
+	ctx context.Context,
+	tx pgx.Tx,
+	meetingID string,
+) (uint, error) {
+	var peak uint
+
+	qry := `
+		SELECT peak_attendees FROM frontend_meetings
+		 WHERE meeting_id = $1
+	`
+
+	err := tx.QueryRow(ctx, qry, meetingID).Scan(&peak)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return peak, nil
+}
+
 // RemoveStaleFrontendMeetings removes all frontend
 // meetings older than a threshold.
 func RemoveStaleFrontendMeetings(
@@ -69,16 +69,93 @@ func TestGetFrontendState(t *testing.T) {
 
 func TestFrontendValidate(t *testing.T) {
 	state := frontendStateFactory()
-	if err := state.Validate(); err != nil {
+	if err := state.Validate(nil); err != nil {
 		t.Error("frontend state should be valid")
 	}
 
 	state = &FrontendState{
 		Frontend: &bbb.Frontend{},
 	}
-	err := state.Validate()
+	err := state.Validate(nil)
 	if err == nil {
 		t.Error("validation should have failed")
 	}
 	t.Log(err)
 }
+
+func TestFrontendValidateRoutingRules(t *testing.T) {
+	state := frontendStateFactory()
+	state.Settings.RoutingRules = []*RoutingRule{
+		{If: "maxParticipants > 200", RequireTags: Tags{"large"}},
+	}
+	if err := state.Validate(nil); err != nil {
+		t.Error("frontend state should be valid:", err)
+	}
+
+	state.Settings.RoutingRules = []*RoutingRule{
+		{If: "maxParticipants > 200", RequireTags: Tags{"large"}},
+		{If: "maxParticipants", RequireTags: Tags{"large"}},
+		{If: "record == true"},
+	}
+	err := state.Validate(nil)
+	if err == nil {
+		t.Fatal("validation should have failed")
+	}
+	if _, ok := err["settings.routing_rules.1.if"]; !ok {
+		t.Error("expected error for condition:", err)
+	}
+	if _, ok := err["settings.routing_rules.2.require_tags"]; !ok {
+		t.Error("expected error for tags:", err)
+	}
+}
+
+func TestFrontendValidateStressStrategy(t *testing.T) {
+	strategies := []string{"test-strategy"}
+
+	state := frontendStateFactory()
+	state.Settings.StressStrategy = "test-strategy"
+	if err := state.Validate(strategies); err != nil {
+		t.Error("frontend state should be valid:", err)
+	}
+
+	state.Settings.StressStrategy = "unknown"
+	err := state.Validate(strategies)
+	if _, ok := err["settings.stress_strategy"]; !ok {
+		t.Error("expected error for stress strategy:", err)
+	}
+}
+
+func TestGetFrontendUsage(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	frontend := frontendStateFactory()
+	if err := frontend.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	m, err := meetingStateFactory(ctx, tx, &MeetingState{
+		ID:         uuid.New().String(),
+		InternalID: uuid.New().String(),
+		FrontendID: &frontend.ID,
+		frontend:   frontend,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Meeting.Attendees = []*bbb.Attendee{{}, {}}
+	if err := m.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := GetFrontendUsage(ctx, tx, frontend.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Meetings != 1 {
+		t.Error("unexpected meetings:", usage.Meetings)
+	}
+	if usage.Attendees != 2 {
+		t.Error("unexpected attendees:", usage.Attendees)
+	}
+}
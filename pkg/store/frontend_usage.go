@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// FrontendUsage is the number of running meetings and
+// attendees of a frontend. Breakout rooms are not counted,
+// as their attendees are also in the parent meeting.
+type FrontendUsage struct {
+	Meetings  uint `json:"meetings"`
+	Attendees uint `json:"attendees"`
+}
+
+// GetFrontendUsage counts the meetings and attendees
+// of a frontend in the cluster.
+func GetFrontendUsage(
+	ctx context.Context,
+	tx pgx.Tx,
+	frontendID string,
+) (*FrontendUsage, error) {
+	qry := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(
+		         CASE WHEN jsonb_typeof(state->'Attendees') = 'array'
+		              THEN jsonb_array_length(state->'Attendees')
+		              ELSE 0
+		         END), 0)
+		  FROM meetings
+		 WHERE frontend_id = $1
+		   AND parent_id IS NULL`
+	usage := &FrontendUsage{}
+	if err := tx.QueryRow(ctx, qry, frontendID).Scan(
+		&usage.Meetings,
+		&usage.Attendees,
+	); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// InstanceStaleTimeout is the duration after which an
+// instance without a heartbeat is considered stale.
+const InstanceStaleTimeout = 30 * time.Second
+
+// InstanceState is the state of a b3scaled
+// instance, reported through heartbeats.
+type InstanceState struct {
+	ID       string `json:"id" doc:"The ID of the instance." example:"b3scale-01:4711"`
+	Hostname string `json:"hostname" doc:"The host running the instance." example:"b3scale-01"`
+	Version  string `json:"version" doc:"The b3scale version of the instance."`
+	Build    string `json:"build" doc:"Build identifier of the instance."`
+
+	StartedAt   time.Time `json:"started_at" doc:"The time the instance was started."`
+	HeartbeatAt time.Time `json:"heartbeat_at" doc:"The last time the instance reported its state."`
+
+	CommandsProcessed int64 `json:"commands_processed" doc:"The number of commands processed by the instance."`
+
+	LastError   *string    `json:"last_error" doc:"The last error of the instance when processing commands."`
+	LastErrorAt *time.Time `json:"last_error_at" doc:"The time of the last error."`
+}
+
+// GetInstanceStates retrieves the states of
+// the instances from the database.
+func GetInstanceStates(
+	ctx context.Context,
+	tx pgx.Tx,
+	q sq.SelectBuilder,
+) ([]*InstanceState, error) {
+	qry, params, _ := q.Columns(
+		"instances.id",
+		"instances.hostname",
+		"instances.version",
+		"instances.build",
+		"instances.started_at",
+		"instances.heartbeat_at",
+		"instances.commands_processed",
+		"instances.last_error",
+		"instances.last_error_at").
+		From("instances").
+		ToSql()
+	rows, err := tx.Query(ctx, qry, params...)
+	if err != nil {
+		return nil, err
+	}
+	cmd := rows.CommandTag()
+	results := make([]*InstanceState, 0, cmd.RowsAffected())
+	for rows.Next() {
+		s := &InstanceState{}
+		err := rows.Scan(
+			&s.ID,
+			&s.Hostname,
+			&s.Version,
+			&s.Build,
+			&s.StartedAt,
+			&s.HeartbeatAt,
+			&s.CommandsProcessed,
+			&s.LastError,
+			&s.LastErrorAt)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, s)
+	}
+	return results, nil
+}
+
+// Heartbeat creates or updates the instance state
+// and sets the heartbeat to now.
+func (s *InstanceState) Heartbeat(
+	ctx context.Context,
+	tx pgx.Tx,
+) error {
+	s.HeartbeatAt = time.Now().UTC()
+	qry := `
+		INSERT INTO instances (
+			id,
+			hostname,
+			version,
+			build,
+			started_at,
+			heartbeat_at,
+			commands_processed,
+			last_error,
+			last_error_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		ON CONFLICT (id) DO UPDATE
+		   SET hostname           = EXCLUDED.hostname,
+		       version            = EXCLUDED.version,
+		       build              = EXCLUDED.build,
+		       started_at         = EXCLUDED.started_at,
+		       heartbeat_at       = EXCLUDED.heartbeat_at,
+		       commands_processed = EXCLUDED.commands_processed,
+		       last_error         = EXCLUDED.last_error,
+		       last_error_at      = EXCLUDED.last_error_at`
+	_, err := tx.Exec(ctx, qry,
+		s.ID,
+		s.Hostname,
+		s.Version,
+		s.Build,
+		s.StartedAt,
+		s.HeartbeatAt,
+		s.CommandsProcessed,
+		s.LastError,
+		s.LastErrorAt)
+	return err
+}
+
+// IsStale checks if the instance did not report
+// its state within the InstanceStaleTimeout.
+func (s *InstanceState) IsStale() bool {
+	return time.Now().UTC().Sub(s.HeartbeatAt) > InstanceStaleTimeout
+}
+
+// RemoveStaleInstances removes all instances
+// without a heartbeat since a threshold.
+func RemoveStaleInstances(
+	ctx context.Context,
+	tx pgx.Tx,
+	t time.Time,
+) error {
+	qry := `
+		DELETE FROM instances
+		 WHERE heartbeat_at < $1
+	`
+	_, err := tx.Exec(ctx, qry, t)
+	return err
+}
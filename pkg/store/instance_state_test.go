@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInstanceStateHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	s := &InstanceState{
+		ID:        "test-host:4711",
+		Hostname:  "test-host",
+		Version:   "1.0.0",
+		Build:     "test",
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.Heartbeat(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := "something failed"
+	s.CommandsProcessed = 42
+	s.LastError = &msg
+	if err := s.Heartbeat(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := GetInstanceStates(ctx, tx, Q().
+		Where("id = ?", s.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 {
+		t.Fatal("unexpected states:", states)
+	}
+	if states[0].CommandsProcessed != 42 {
+		t.Error("unexpected commands processed:", states[0].CommandsProcessed)
+	}
+	if *states[0].LastError != msg {
+		t.Error("unexpected last error:", *states[0].LastError)
+	}
+
+	// Remove the instance
+	if err := RemoveStaleInstances(
+		ctx, tx, time.Now().UTC().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	states, err = GetInstanceStates(ctx, tx, Q().
+		Where("id = ?", s.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 0 {
+		t.Error("the stale instance should be removed")
+	}
+}
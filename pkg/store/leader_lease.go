@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// A LeaderLease is held by a single instance
+// until it expires.
+type LeaderLease struct {
+	Name       string    `json:"name" doc:"The name of the lease." example:"controller"`
+	Holder     string    `json:"holder" doc:"The ID of the instance holding the lease." example:"b3scale-01:4711"`
+	AcquiredAt time.Time `json:"acquired_at" doc:"The time the holder acquired the lease."`
+	ExpiresAt  time.Time `json:"expires_at" doc:"Another instance takes over, if the lease is not renewed before it expires."`
+}
+
+// AcquireLeaderLease acquires or renews a lease for the
+// holder. The lease is only acquired, if it is not held by
+// another instance or if it expired. The result
+// indicates if the holder is the leader.
+// The clock of the database is used, so instances
+// with skewed clocks agree on the expiry.
+func AcquireLeaderLease(
+	ctx context.Context,
+	tx pgx.Tx,
+	name string,
+	holder string,
+	ttl time.Duration,
+) (bool, error) {
+	qry := `
+		INSERT INTO leader_leases (
+			name, holder, acquired_at, expires_at
+		) VALUES (
+			$1, $2,
+			now() AT TIME ZONE 'utc',
+			(now() AT TIME ZONE 'utc') + $3 * interval '1 millisecond'
+		)
+		ON CONFLICT (name) DO UPDATE
+		   SET holder      = EXCLUDED.holder,
+		       acquired_at = CASE
+		         WHEN leader_leases.holder = EXCLUDED.holder
+		         THEN leader_leases.acquired_at
+		         ELSE EXCLUDED.acquired_at
+		       END,
+		       expires_at  = EXCLUDED.expires_at
+		 WHERE leader_leases.holder = EXCLUDED.holder
+		    OR leader_leases.expires_at < EXCLUDED.acquired_at
+		RETURNING holder`
+	var current string
+	err := tx.QueryRow(ctx, qry, name, holder, ttl.Milliseconds()).
+		Scan(&current)
+	if err == pgx.ErrNoRows {
+		return false, nil // The lease is held by another instance
+	}
+	if err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// GetLeaderLease retrieves the current lease.
+// This may return nil without an error.
+func GetLeaderLease(
+	ctx context.Context,
+	tx pgx.Tx,
+	name string,
+) (*LeaderLease, error) {
+	qry := `
+		SELECT name, holder, acquired_at, expires_at
+		  FROM leader_leases
+		 WHERE name = $1`
+	lease := &LeaderLease{}
+	err := tx.QueryRow(ctx, qry, name).Scan(
+		&lease.Name,
+		&lease.Holder,
+		&lease.AcquiredAt,
+		&lease.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
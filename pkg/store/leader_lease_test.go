@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquireLeaderLease(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	name := "test-lease"
+
+	leader, err := AcquireLeaderLease(ctx, tx, name, "instance-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leader {
+		t.Error("instance-1 should acquire the lease")
+	}
+
+	// The lease is held by instance-1
+	leader, err = AcquireLeaderLease(ctx, tx, name, "instance-2", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader {
+		t.Error("instance-2 should not acquire the lease")
+	}
+
+	// Renew
+	leader, err = AcquireLeaderLease(ctx, tx, name, "instance-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leader {
+		t.Error("instance-1 should renew the lease")
+	}
+
+	// Let the lease expire and take over
+	if _, err := tx.Exec(ctx, `
+		UPDATE leader_leases
+		   SET expires_at = (now() AT TIME ZONE 'utc') - interval '1 second'
+		 WHERE name = $1`,
+		name); err != nil {
+		t.Fatal(err)
+	}
+	leader, err = AcquireLeaderLease(ctx, tx, name, "instance-2", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leader {
+		t.Error("instance-2 should take over the expired lease")
+	}
+
+	lease, err := GetLeaderLease(ctx, tx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Holder != "instance-2" {
+		t.Error("unexpected holder:", lease.Holder)
+	}
+}
@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// Maintenance modes
+const (
+	// MaintenanceDrain stops routing new meetings to
+	// the backend during the maintenance window.
+	MaintenanceDrain = "drain"
+
+	// MaintenanceForce additionally ends the remaining
+	// meetings on the backend at the deadline.
+	MaintenanceForce = "force"
+)
+
+// Maintenance window states
+const (
+	MaintenanceScheduled = "scheduled"
+	MaintenanceActive    = "active"
+	MaintenanceDone      = "done"
+)
+
+// A MaintenanceWindow is a scheduled period of time
+// in which a backend does not accept new meetings.
+type MaintenanceWindow struct {
+	ID        string `json:"id"`
+	BackendID string `json:"backend_id" doc:"The ID of the backend under maintenance."`
+
+	Mode  string `json:"mode" doc:"In drain mode, new meetings are no longer created on the backend. In force mode, the remaining meetings are ended at the deadline." example:"drain" enum:"drain,force"`
+	State string `json:"state" doc:"The state of the maintenance window. The backend is re-enabled when the window is done." example:"scheduled" enum:"scheduled,active,done"`
+
+	StartsAt time.Time  `json:"starts_at" doc:"The backend is disabled at the start of the window."`
+	EndsAt   time.Time  `json:"ends_at" doc:"The backend is enabled again at the end of the window."`
+	Deadline *time.Time `json:"deadline" doc:"In force mode, the remaining meetings are ended at the deadline. Defaults to the start of the window."`
+
+	DisabledBackend bool `json:"disabled_backend" doc:"The backend was disabled by the window and is enabled again at the end, unless another window is active."`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InitMaintenanceWindow initializes a maintenance
+// window with default values.
+func InitMaintenanceWindow(init *MaintenanceWindow) *MaintenanceWindow {
+	if init.Mode == "" {
+		init.Mode = MaintenanceDrain
+	}
+	if init.State == "" {
+		init.State = MaintenanceScheduled
+	}
+	return init
+}
+
+// GetMaintenanceWindows retrieves maintenance windows
+// from the database.
+func GetMaintenanceWindows(
+	ctx context.Context,
+	tx pgx.Tx,
+	q sq.SelectBuilder,
+) ([]*MaintenanceWindow, error) {
+	qry, params, _ := q.Columns(
+		"maintenance_windows.id",
+		"maintenance_windows.backend_id",
+		"maintenance_windows.mode",
+		"maintenance_windows.state",
+		"maintenance_windows.starts_at",
+		"maintenance_windows.ends_at",
+		"maintenance_windows.deadline",
+		"maintenance_windows.disabled_backend",
+		"maintenance_windows.created_at",
+		"maintenance_windows.updated_at").
+		From("maintenance_windows").
+		ToSql()
+	rows, err := tx.Query(ctx, qry, params...)
+	if err != nil {
+		return nil, err
+	}
+	cmd := rows.CommandTag()
+	results := make([]*MaintenanceWindow, 0, cmd.RowsAffected())
+	for rows.Next() {
+		w := InitMaintenanceWindow(&MaintenanceWindow{})
+		err := rows.Scan(
+			&w.ID,
+			&w.BackendID,
+			&w.Mode,
+			&w.State,
+			&w.StartsAt,
+			&w.EndsAt,
+			&w.Deadline,
+			&w.DisabledBackend,
+			&w.CreatedAt,
+			&w.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, w)
+	}
+	return results, nil
+}
+
+// GetMaintenanceWindow retrieves a single maintenance
+// window. This may return nil without an error.
+func GetMaintenanceWindow(
+	ctx context.Context,
+	tx pgx.Tx,
+	q sq.SelectBuilder,
+) (*MaintenanceWindow, error) {
+	windows, err := GetMaintenanceWindows(ctx, tx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	return windows[0], nil
+}
+
+// Save will create or update a maintenance window
+func (w *MaintenanceWindow) Save(
+	ctx context.Context,
+	tx pgx.Tx,
+) error {
+	if w.CreatedAt.IsZero() {
+		return w.insert(ctx, tx)
+	}
+	return w.update(ctx, tx)
+}
+
+// insert creates a new maintenance window
+func (w *MaintenanceWindow) insert(ctx context.Context, tx pgx.Tx) error {
+	qry := `
+		INSERT INTO maintenance_windows (
+			backend_id, mode, state, starts_at, ends_at, deadline,
+			disabled_backend
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		RETURNING id, created_at, updated_at`
+	return tx.QueryRow(ctx, qry,
+		w.BackendID,
+		w.Mode,
+		w.State,
+		w.StartsAt,
+		w.EndsAt,
+		w.Deadline,
+		w.DisabledBackend).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// update the maintenance window in the database
+func (w *MaintenanceWindow) update(ctx context.Context, tx pgx.Tx) error {
+	w.UpdatedAt = time.Now().UTC()
+	qry := `
+		UPDATE maintenance_windows
+		   SET mode       = $2,
+		       state      = $3,
+		       starts_at  = $4,
+		       ends_at    = $5,
+		       deadline   = $6,
+		       updated_at = $7,
+		       disabled_backend = $8
+		 WHERE id = $1`
+	_, err := tx.Exec(ctx, qry,
+		w.ID,
+		// Values
+		w.Mode,
+		w.State,
+		w.StartsAt,
+		w.EndsAt,
+		w.Deadline,
+		w.UpdatedAt,
+		w.DisabledBackend)
+	return err
+}
+
+// Delete removes the maintenance window
+func (w *MaintenanceWindow) Delete(ctx context.Context, tx pgx.Tx) error {
+	qry := `
+		DELETE FROM maintenance_windows WHERE id = $1
+	`
+	_, err := tx.Exec(ctx, qry, w.ID)
+	return err
+}
+
+// Validate checks the mode and the times of the window.
+func (w *MaintenanceWindow) Validate() ValidationError {
+	err := ValidationError{}
+
+	if w.BackendID == "" {
+		err.Add("backend_id", ErrFieldRequired)
+	}
+	if w.Mode != MaintenanceDrain && w.Mode != MaintenanceForce {
+		err.Add("mode", "must be one of: drain, force")
+	}
+	if w.StartsAt.IsZero() {
+		err.Add("starts_at", ErrFieldRequired)
+	}
+	if w.EndsAt.IsZero() {
+		err.Add("ends_at", ErrFieldRequired)
+	}
+	if !w.StartsAt.IsZero() && !w.EndsAt.After(w.StartsAt) {
+		err.Add("ends_at", "must be after starts_at")
+	}
+	if w.Deadline != nil {
+		if w.Mode != MaintenanceForce {
+			err.Add("deadline", "requires mode force")
+		}
+		if w.Deadline.Before(w.StartsAt) || !w.Deadline.Before(w.EndsAt) {
+			err.Add("deadline", "must be within the window")
+		}
+	}
+
+	if len(err) > 0 {
+		return err
+	}
+	return nil
+}
+
+// NextState is the state the window should be in
+// at a point in time.
+func (w *MaintenanceWindow) NextState(now time.Time) string {
+	if w.State == MaintenanceDone || !now.Before(w.EndsAt) {
+		return MaintenanceDone
+	}
+	if !now.Before(w.StartsAt) {
+		return MaintenanceActive
+	}
+	return MaintenanceScheduled
+}
+
+// DeadlineReached checks if the remaining meetings on
+// the backend should be ended. This is only the case in
+// force mode while the window is active.
+func (w *MaintenanceWindow) DeadlineReached(now time.Time) bool {
+	if w.Mode != MaintenanceForce {
+		return false
+	}
+	if w.NextState(now) != MaintenanceActive {
+		return false
+	}
+	deadline := w.StartsAt
+	if w.Deadline != nil {
+		deadline = *w.Deadline
+	}
+	return !now.Before(deadline)
+}
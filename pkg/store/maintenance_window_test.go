@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowSave(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	backend := backendStateFactory()
+	if err := backend.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	w := InitMaintenanceWindow(&MaintenanceWindow{
+		BackendID: backend.ID,
+		StartsAt:  start,
+		EndsAt:    start.Add(2 * time.Hour),
+	})
+	if err := w.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if w.ID == "" {
+		t.Fatal("expected an ID")
+	}
+
+	w.Mode = MaintenanceForce
+	w.DisabledBackend = true
+	if err := w.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	dbWindow, err := GetMaintenanceWindow(ctx, tx, Q().
+		Where("backend_id = ?", backend.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dbWindow.Mode != MaintenanceForce {
+		t.Error("unexpected mode:", dbWindow.Mode)
+	}
+	if dbWindow.State != MaintenanceScheduled {
+		t.Error("unexpected state:", dbWindow.State)
+	}
+	if !dbWindow.DisabledBackend {
+		t.Error("expected disabled backend")
+	}
+	if !dbWindow.StartsAt.Equal(start) {
+		t.Error("unexpected start:", dbWindow.StartsAt)
+	}
+
+	if err := dbWindow.Delete(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	dbWindow, err = GetMaintenanceWindow(ctx, tx, Q().
+		Where("backend_id = ?", backend.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dbWindow != nil {
+		t.Error("window should be deleted")
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	start := time.Now().UTC()
+	w := InitMaintenanceWindow(&MaintenanceWindow{
+		BackendID: "backend",
+		StartsAt:  start,
+		EndsAt:    start.Add(-time.Hour),
+	})
+	err := w.Validate()
+	if err == nil || err["ends_at"] == nil {
+		t.Error("expected an error for ends_at:", err)
+	}
+
+	deadline := start.Add(time.Hour)
+	w.EndsAt = start.Add(2 * time.Hour)
+	w.Deadline = &deadline
+	err = w.Validate()
+	if err == nil || err["deadline"] == nil {
+		t.Error("expected an error for deadline in drain mode:", err)
+	}
+
+	w.Mode = MaintenanceForce
+	if err := w.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaintenanceWindowNextState(t *testing.T) {
+	start := time.Now().UTC()
+	w := InitMaintenanceWindow(&MaintenanceWindow{
+		Mode:     MaintenanceForce,
+		StartsAt: start,
+		EndsAt:   start.Add(2 * time.Hour),
+	})
+
+	if s := w.NextState(start.Add(-time.Minute)); s != MaintenanceScheduled {
+		t.Error("unexpected state:", s)
+	}
+	if s := w.NextState(start); s != MaintenanceActive {
+		t.Error("unexpected state:", s)
+	}
+	if s := w.NextState(w.EndsAt); s != MaintenanceDone {
+		t.Error("unexpected state:", s)
+	}
+
+	// Without a deadline, meetings are ended at the start
+	if w.DeadlineReached(start.Add(-time.Minute)) {
+		t.Error("deadline should not be reached before the start")
+	}
+	if !w.DeadlineReached(start) {
+		t.Error("deadline should be reached at the start")
+	}
+
+	deadline := start.Add(time.Hour)
+	w.Deadline = &deadline
+	if w.DeadlineReached(start.Add(30 * time.Minute)) {
+		t.Error("deadline should not be reached")
+	}
+	if !w.DeadlineReached(deadline) {
+		t.Error("deadline should be reached")
+	}
+	if w.DeadlineReached(w.EndsAt) {
+		t.Error("deadline should not apply after the window")
+	}
+
+	w.Mode = MaintenanceDrain
+	if w.DeadlineReached(deadline) {
+		t.Error("meetings should not be ended in drain mode")
+	}
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// MeetingLockTimeout is the maximum duration to wait
+// for a lock held by a concurrent request.
+const MeetingLockTimeout = 30 * time.Second
+
+// meetingLockRetryInterval is the interval for
+// polling the lock while it is held by another request.
+const meetingLockRetryInterval = 150 * time.Millisecond
+
+// A MeetingLock is an advisory lock for a meeting
+// of a frontend, shared across all instances. The lock
+// is held by a transaction on a dedicated connection, so
+// it works with transaction pooling. It must be released.
+type MeetingLock struct {
+	tx  pgx.Tx
+	key string
+}
+
+// AcquireMeetingLock polls the database for an advisory
+// lock until the context expires. The lock is identified
+// by the frontend key and the meeting ID.
+func AcquireMeetingLock(
+	ctx context.Context,
+	frontendKey string,
+	meetingID string,
+) (*MeetingLock, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, ErrDeadlineRequired
+	}
+	tx, err := begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lock := &MeetingLock{
+		tx:  tx,
+		key: "meeting:" + frontendKey + ":" + meetingID,
+	}
+	qry := `SELECT pg_try_advisory_xact_lock(hashtext($1))`
+	for {
+		acquired := false
+		if err := tx.QueryRow(ctx, qry, lock.key).Scan(&acquired); err != nil {
+			lock.Release()
+			return nil, err
+		}
+		if acquired {
+			return lock, nil
+		}
+		select {
+		case <-ctx.Done():
+			lock.Release()
+			return nil, ctx.Err() // context was canceled or expired
+		case <-time.After(meetingLockRetryInterval):
+		}
+	}
+}
+
+// Release the advisory lock by ending the transaction.
+// The lock is released even if the context of the request
+// was canceled, so the connection is returned to the pool.
+func (l *MeetingLock) Release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.tx.Rollback(ctx); err != nil && err != pgx.ErrTxClosed {
+		log.Error().
+			Err(err).
+			Str("lock", l.key).
+			Msg("could not release meeting lock")
+	}
+}
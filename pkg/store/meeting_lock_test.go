@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireMeetingLock(t *testing.T) {
+	ctx := context.Background()
+
+	lockCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	lock, err := AcquireMeetingLock(lockCtx, "frontend", "meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is held by the first transaction
+	waitCtx, cancelWait := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancelWait()
+	_, err = AcquireMeetingLock(waitCtx, "frontend", "meeting")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected deadline exceeded, got:", err)
+	}
+
+	// Other meetings are not affected
+	other, err := AcquireMeetingLock(lockCtx, "frontend", "meeting2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Release()
+
+	lock.Release()
+	lock, err = AcquireMeetingLock(lockCtx, "frontend", "meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Release()
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
+
+// A MeetingCreateRequest is the create request of the
+// meeting as it was sent to the backend.
+type MeetingCreateRequest struct {
+	Params bbb.Params `json:"params"`
+	Body   []byte     `json:"body,omitempty"`
+}
+
+// SetCreateRequest stores the create request of the meeting.
+func (s *MeetingState) SetCreateRequest(
+	ctx context.Context,
+	tx pgx.Tx,
+	req *MeetingCreateRequest,
+) error {
+	qry := `
+		UPDATE meetings
+		   SET create_request = $2
+		 WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, qry, s.ID, req); err != nil {
+		return err
+	}
+	s.CreateRequest = req
+	return nil
+}
+
+// RebindBackend changes the backend of the meeting, but
+// only if the meeting is still bound to the expected
+// backend. When the meeting was bound to another backend
+// in the meantime, false is returned.
+//
+// Setting the backend to nil claims the meeting for
+// recovery: Concurrent attempts will fail.
+func (s *MeetingState) RebindBackend(
+	ctx context.Context,
+	tx pgx.Tx,
+	from *string,
+	to *string,
+) (bool, error) {
+	qry := `
+		UPDATE meetings
+		   SET backend_id = $3
+		 WHERE id = $1
+		   AND backend_id IS NOT DISTINCT FROM $2
+	`
+	cmd, err := tx.Exec(ctx, qry, s.ID, from, to)
+	if err != nil {
+		return false, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return false, nil
+	}
+	s.BackendID = to
+	s.backend = nil
+
+	// The meeting is no longer counted on the
+	// previous backend.
+	if from != nil {
+		if err := updateBackendStatCounters(ctx, tx, *from); err != nil {
+			return false, err
+		}
+	}
+	if to != nil {
+		if err := updateBackendStatCounters(ctx, tx, *to); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
+
+func TestMeetingStateSetCreateRequest(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state, err := meetingStateFactory(ctx, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &MeetingCreateRequest{
+		Params: bbb.Params{
+			bbb.ParamMeetingID: state.ID,
+			"name":             "Meeting",
+		},
+		Body: []byte("<modules></modules>"),
+	}
+	if err := state.SetCreateRequest(ctx, tx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = GetMeetingStateByID(ctx, tx, state.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CreateRequest == nil {
+		t.Fatal("expected create request")
+	}
+	if state.CreateRequest.Params["name"] != "Meeting" {
+		t.Error("unexpected params:", state.CreateRequest.Params)
+	}
+	if string(state.CreateRequest.Body) != "<modules></modules>" {
+		t.Error("unexpected body:", string(state.CreateRequest.Body))
+	}
+}
+
+func TestMeetingStateRebindBackend(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state, err := meetingStateFactory(ctx, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := backendStateFactory()
+	if err := other.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	prev := state.BackendID
+
+	// Claim the meeting
+	ok, err := state.RebindBackend(ctx, tx, prev, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected meeting to be claimed")
+	}
+
+	// A concurrent claim must fail
+	ok, err = state.RebindBackend(ctx, tx, prev, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("meeting should not be claimed twice")
+	}
+
+	ok, err = state.RebindBackend(ctx, tx, nil, &other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected meeting to be rebound")
+	}
+	state, err = GetMeetingStateByID(ctx, tx, state.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *state.BackendID != other.ID {
+		t.Error("unexpected backend:", *state.BackendID)
+	}
+}
@@ -33,6 +33,14 @@ type MeetingState struct {
 	BackendID *string `json:"backend_id"`
 	backend   *BackendState
 
+	ParentID *string `json:"parent_id" doc:"The meeting ID of the parent meeting, if this meeting is a breakout room."`
+
+	// CreateRequest is used for recovering the meeting
+	// and is not exposed through the API.
+	CreateRequest *MeetingCreateRequest `json:"-"`
+
+	EmptySince *time.Time `json:"empty_since" doc:"The time since the meeting has no attendees."`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	SyncedAt  time.Time `json:"synced_at"`
@@ -57,6 +65,9 @@ func GetMeetingStates(
 		"meetings.internal_id",
 		"meetings.frontend_id",
 		"meetings.backend_id",
+		"meetings.parent_id",
+		"meetings.create_request",
+		"meetings.empty_since",
 		"meetings.state",
 		"meetings.created_at",
 		"meetings.updated_at",
@@ -153,6 +164,9 @@ func meetingStateFromRow(
 		&state.InternalID,
 		&state.FrontendID,
 		&state.BackendID,
+		&state.ParentID,
+		&state.CreateRequest,
+		&state.EmptySince,
 		&state.Meeting,
 		&state.CreatedAt,
 		&state.UpdatedAt,
@@ -340,6 +354,9 @@ func (s *MeetingState) Save(ctx context.Context, tx pgx.Tx) error {
 		err error
 		id  string
 	)
+	if err := s.resolveParentID(ctx, tx); err != nil {
+		return err
+	}
 	if s.CreatedAt.IsZero() {
 		id, err = s.insert(ctx, tx)
 		s.ID = id
@@ -375,16 +392,22 @@ func (s *MeetingState) insert(ctx context.Context, tx pgx.Tx) (string, error) {
 			state,
 
 			frontend_id,
-			backend_id
+			backend_id,
+			parent_id,
+
+			empty_since
 		) VALUES (
-			$1, $2, $3, $4, $5
+			$1, $2, $3, $4, $5, $6,
+			CASE WHEN $7::integer = 0 THEN now() END
 		) RETURNING id`
 	err := tx.QueryRow(ctx, qry,
 		s.Meeting.MeetingID,
 		s.Meeting.InternalMeetingID,
 		s.Meeting,
 		s.FrontendID,
-		s.BackendID).Scan(&s.ID)
+		s.BackendID,
+		s.ParentID,
+		s.attendeesCount()).Scan(&s.ID)
 	if err != nil {
 		return "", err
 	}
@@ -402,7 +425,11 @@ func (s *MeetingState) update(ctx context.Context, tx pgx.Tx) error {
 		       frontend_id  = $4,
 			   backend_id   = $5,
 		  	   synced_at    = $6,
-			   updated_at   = $7 
+			   updated_at   = $7,
+			   parent_id    = $8,
+			   empty_since  = CASE WHEN $9::integer = 0
+			                  THEN COALESCE(empty_since, now())
+			                  END
 	 	 WHERE id = $1`
 	_, err := tx.Exec(ctx, qry,
 		s.ID,
@@ -411,7 +438,9 @@ func (s *MeetingState) update(ctx context.Context, tx pgx.Tx) error {
 		s.FrontendID,
 		s.BackendID,
 		s.SyncedAt,
-		s.UpdatedAt)
+		s.UpdatedAt,
+		s.ParentID,
+		s.attendeesCount())
 	return err
 }
 
@@ -428,19 +457,52 @@ func (s *MeetingState) updateFrontendMeetingMapping(
 	qry := `
 		INSERT INTO frontend_meetings (
 			frontend_id,
-			meeting_id
+			meeting_id,
+			peak_attendees
 		) VALUES (
-			$1, $2
+			$1, $2, $3
 		) ON CONFLICT (meeting_id) DO UPDATE
-		  SET seen_at = CURRENT_TIMESTAMP
+		  SET seen_at = CURRENT_TIMESTAMP,
+		      peak_attendees = GREATEST(
+			      frontend_meetings.peak_attendees,
+			      EXCLUDED.peak_attendees)
 	`
-	_, err := tx.Exec(ctx, qry, *s.FrontendID, s.ID)
+	_, err := tx.Exec(ctx, qry, *s.FrontendID, s.ID, s.attendeesCount())
 	return err
 }
 
+// Private updatePeakAttendees raises the peak attendees
+// of the meeting in the `frontend_meetings` table, if
+// the meeting is known.
+func (s *MeetingState) updatePeakAttendees(
+	ctx context.Context,
+	tx pgx.Tx,
+) error {
+	qry := `
+		UPDATE frontend_meetings
+		   SET peak_attendees = $2
+		 WHERE meeting_id = $1
+		   AND peak_attendees < $2
+	`
+	_, err := tx.Exec(ctx, qry, s.ID, s.attendeesCount())
+	return err
+}
+
+// Private attendeesCount is the number of
+// attendees in the meeting.
+func (s *MeetingState) attendeesCount() int {
+	if s.Meeting == nil {
+		return 0
+	}
+	return len(s.Meeting.Attendees)
+}
+
 // Upsert meeting state will create the meeting state
 // or will fall back to a state update.
 func (s *MeetingState) Upsert(ctx context.Context, tx pgx.Tx) (string, error) {
+	if err := s.resolveParentID(ctx, tx); err != nil {
+		return "", err
+	}
 	qry := `
 		INSERT INTO meetings (
 			id,
@@ -450,17 +512,24 @@ func (s *MeetingState) Upsert(ctx context.Context, tx pgx.Tx) (string, error) {
 
 			frontend_id,
 			backend_id,
+			parent_id,
 
 			updated_at,
-			synced_at
+			synced_at,
 
+			empty_since
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			CASE WHEN $9::integer = 0 THEN now() END
 		)
 		ON CONFLICT ON CONSTRAINT meetings_pkey DO UPDATE
 		   SET state		= EXCLUDED.state,
+		       parent_id    = COALESCE(EXCLUDED.parent_id, meetings.parent_id),
 		  	   synced_at    = EXCLUDED.synced_at,
-			   updated_at   = EXCLUDED.updated_at
+			   updated_at   = EXCLUDED.updated_at,
+			   empty_since  = CASE WHEN EXCLUDED.empty_since IS NOT NULL
+			                  THEN COALESCE(meetings.empty_since, EXCLUDED.empty_since)
+			                  END
 		RETURNING id`
 
 	err := tx.QueryRow(ctx, qry,
@@ -469,12 +538,18 @@ func (s *MeetingState) Upsert(ctx context.Context, tx pgx.Tx) (string, error) {
 		s.Meeting,
 		s.FrontendID,
 		s.BackendID,
+		s.ParentID,
 		s.UpdatedAt,
-		s.SyncedAt).Scan(&s.ID)
+		s.SyncedAt,
+		s.attendeesCount()).Scan(&s.ID)
 	if err != nil {
 		return "", err
 	}
 
+	if err := s.updatePeakAttendees(ctx, tx); err != nil {
+		return "", err
+	}
+
 	return s.ID, nil
 }
 
@@ -495,6 +570,46 @@ func (s *MeetingState) SetBackendID(
 	return err
 }
 
+// IsBreakout checks if the meeting is a breakout room
+func (s *MeetingState) IsBreakout() bool {
+	if s.ParentID != nil {
+		return true
+	}
+	return s.Meeting != nil && s.Meeting.IsBreakout
+}
+
+// resolveParentID looks up the parent meeting of a
+// breakout room by its internal meeting ID.
+func (s *MeetingState) resolveParentID(
+	ctx context.Context,
+	tx pgx.Tx,
+) error {
+	if s.ParentID != nil || s.Meeting == nil {
+		return nil // nothing to do here
+	}
+	if !s.Meeting.IsBreakout || s.Meeting.Breakout == nil {
+		return nil
+	}
+	parentInternalID := s.Meeting.Breakout.ParentMeetingID
+	if parentInternalID == "" {
+		return nil
+	}
+
+	var parentID string
+	qry := `
+		SELECT id FROM meetings WHERE internal_id = $1
+	`
+	err := tx.QueryRow(ctx, qry, parentInternalID).Scan(&parentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // The parent is not (yet) known
+	}
+	if err != nil {
+		return err
+	}
+	s.ParentID = &parentID
+	return nil
+}
+
 // BindFrontendID associates an unclaimed meeting with a frontend
 func (s *MeetingState) BindFrontendID(
 	ctx context.Context,
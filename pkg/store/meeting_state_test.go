@@ -405,3 +405,125 @@ func TestMeetingStateUpdateFrontendMeetingMapping(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestMeetingStateResolveParentID(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	parent, err := meetingStateFactory(ctx, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parent.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	breakout, err := meetingStateFactory(ctx, tx, &MeetingState{
+		ID:         uuid.New().String(),
+		InternalID: uuid.New().String(),
+		Meeting: &bbb.Meeting{
+			IsBreakout: true,
+			Breakout: &bbb.Breakout{
+				ParentMeetingID: parent.InternalID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := breakout.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if breakout.ParentID == nil {
+		t.Fatal("expected parent id to be set")
+	}
+	if *breakout.ParentID != parent.ID {
+		t.Error("unexpected parent id:", *breakout.ParentID)
+	}
+	if !breakout.IsBreakout() {
+		t.Error("expected meeting to be a breakout room")
+	}
+}
+
+func TestMeetingStatePeakAttendees(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	frontend := frontendStateFactory()
+	if err := frontend.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MeetingState{
+		ID:         "meeting23422",
+		FrontendID: &frontend.ID,
+		Meeting: &bbb.Meeting{
+			Attendees: []*bbb.Attendee{{}, {}, {}},
+		},
+	}
+	if err := m.updateFrontendMeetingMapping(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The peak should not decrease
+	m.Meeting.Attendees = []*bbb.Attendee{{}}
+	if err := m.updatePeakAttendees(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.updateFrontendMeetingMapping(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	peak, err := LookupPeakAttendees(ctx, tx, m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak != 3 {
+		t.Error("unexpected peak attendees:", peak)
+	}
+
+	peak, err = LookupPeakAttendees(ctx, tx, "unknown-meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak != 0 {
+		t.Error("unexpected peak attendees:", peak)
+	}
+}
+
+func TestMeetingStateEmptySince(t *testing.T) {
+	ctx := context.Background()
+	tx := beginTest(ctx, t)
+	defer tx.Rollback(ctx)
+
+	state, err := meetingStateFactory(ctx, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.EmptySince == nil {
+		t.Fatal("expected empty since")
+	}
+	since := *state.EmptySince
+
+	// Saving again should not reset the time
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if !state.EmptySince.Equal(since) {
+		t.Error("unexpected empty since:", state.EmptySince)
+	}
+
+	state.Meeting.Attendees = []*bbb.Attendee{{}}
+	if err := state.Save(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if state.EmptySince != nil {
+		t.Error("did not expect empty since:", state.EmptySince)
+	}
+}
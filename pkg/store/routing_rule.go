@@ -0,0 +1,104 @@
+package store
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
+
+// Errors
+var (
+	// ErrInvalidCondition is returned when a condition
+	// of a routing rule can not be parsed.
+	ErrInvalidCondition = errors.New(
+		"condition must be: <param> <op> <value>, with op one of ==, !=, >, >=, <, <=")
+)
+
+// A RoutingRule requires tags for a meeting when the
+// condition matches the parameters of the create request.
+type RoutingRule struct {
+	If          string `json:"if" doc:"A condition on a parameter of the create request: <param> <op> <value>. The operator is one of ==, !=, >, >=, <, <=. Values are compared as numbers if possible." example:"maxParticipants > 200"`
+	RequireTags Tags   `json:"require_tags" doc:"When the condition matches, only backends providing all of these tags are considered."`
+}
+
+// Match checks if the condition of the rule matches
+// the params of a request.
+func (r *RoutingRule) Match(params bbb.Params) (bool, error) {
+	cond, err := ParseRuleCondition(r.If)
+	if err != nil {
+		return false, err
+	}
+	return cond.Match(params), nil
+}
+
+// A RuleCondition compares a request parameter
+// with a value.
+type RuleCondition struct {
+	Param string
+	Op    string
+	Value string
+}
+
+var ruleConditionExpr = regexp.MustCompile(
+	`^\s*([^\s=!<>]+)\s*(==|!=|>=|<=|>|<)\s*(.*?)\s*$`)
+
+// ParseRuleCondition parses a condition expression
+// like `meta_course-type == exam`.
+func ParseRuleCondition(expr string) (*RuleCondition, error) {
+	match := ruleConditionExpr.FindStringSubmatch(expr)
+	if match == nil {
+		return nil, ErrInvalidCondition
+	}
+	value := match[3]
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	return &RuleCondition{
+		Param: match[1],
+		Op:    match[2],
+		Value: value,
+	}, nil
+}
+
+// Match checks the condition against the params.
+// If the parameter is not present, the condition
+// does not match.
+func (c *RuleCondition) Match(params bbb.Params) bool {
+	value, ok := params[c.Param]
+	if !ok {
+		return false
+	}
+	value = strings.TrimSpace(value)
+
+	// Compare as numbers if possible
+	a, errA := strconv.ParseFloat(value, 64)
+	b, errB := strconv.ParseFloat(c.Value, 64)
+	if errA == nil && errB == nil {
+		switch c.Op {
+		case "==":
+			return a == b
+		case "!=":
+			return a != b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		}
+		return false
+	}
+
+	switch c.Op {
+	case "==":
+		return value == c.Value
+	case "!=":
+		return value != c.Value
+	}
+	return false // ordering requires numbers
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
+
+func TestParseRuleCondition(t *testing.T) {
+	cond, err := ParseRuleCondition("meta_course-type == exam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond.Param != "meta_course-type" || cond.Op != "==" || cond.Value != "exam" {
+		t.Error("unexpected condition:", cond)
+	}
+
+	cond, err = ParseRuleCondition(`name!="Big Room"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond.Param != "name" || cond.Op != "!=" || cond.Value != "Big Room" {
+		t.Error("unexpected condition:", cond)
+	}
+
+	for _, expr := range []string{"", "maxParticipants", "== 200", "a => b"} {
+		if _, err := ParseRuleCondition(expr); err == nil {
+			t.Error("expected error for:", expr)
+		}
+	}
+}
+
+func TestRoutingRuleMatch(t *testing.T) {
+	params := bbb.Params{
+		"maxParticipants":  "250",
+		"meta_course-type": "exam",
+	}
+	tests := map[string]bool{
+		"maxParticipants > 200":    true,
+		"maxParticipants >= 250":   true,
+		"maxParticipants < 200":    false,
+		"maxParticipants == 250.0": true,
+		"meta_course-type == exam": true,
+		"meta_course-type != exam": false,
+		"meta_course-type > 10":    false,
+		"record == true":           false, // missing
+	}
+	for expr, expected := range tests {
+		rule := &RoutingRule{If: expr}
+		match, err := rule.Match(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match != expected {
+			t.Error("unexpected result for", expr, ":", match)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+
+
+--
+-- Backend Capacity
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+ALTER TABLE backends
+  ADD video_streams_count INTEGER NOT NULL DEFAULT 0;
+ 
@@ -0,0 +1,25 @@
+
+
+--
+-- Backend Reservations
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- Reservations are created when a backend was selected
+-- for a new meeting. They are removed when the meeting
+-- was created or when the backend was synced.
+CREATE TABLE backend_reservations (
+    backend_id uuid         NOT NULL
+               REFERENCES   backends(id)
+               ON DELETE    CASCADE,
+
+    meeting_id VARCHAR(255) NOT NULL,
+
+    created_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX backend_reservations_backend_id_idx
+          ON backend_reservations(backend_id);
+ 
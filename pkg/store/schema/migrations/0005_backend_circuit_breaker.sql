@@ -0,0 +1,14 @@
+
+
+--
+-- Backend Circuit Breaker
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The circuit breaker tracks request errors
+-- of the backend across all instances.
+ALTER TABLE backends
+  ADD circuit jsonb NOT NULL DEFAULT '{"state": "closed"}'::jsonb;
+ 
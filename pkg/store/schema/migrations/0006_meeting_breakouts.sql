@@ -0,0 +1,17 @@
+
+
+--
+-- Breakout Rooms
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- Breakout rooms are associated with their
+-- parent meeting.
+ALTER TABLE meetings
+  ADD parent_id VARCHAR(255) NULL;
+
+CREATE INDEX meetings_parent_id_idx
+          ON meetings(parent_id);
+ 
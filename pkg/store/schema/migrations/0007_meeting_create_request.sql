@@ -0,0 +1,13 @@
+
+
+--
+-- Meeting Create Request
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The create request is stored with the meeting,
+-- so the meeting can be recreated on another backend.
+ALTER TABLE meetings
+  ADD create_request jsonb NULL;
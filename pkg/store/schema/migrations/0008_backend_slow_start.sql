@@ -0,0 +1,13 @@
+
+
+--
+-- Backend Slow Start
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The time when the backend became ready. New meetings
+-- on the backend are limited during a warm-up period.
+ALTER TABLE backends
+  ADD ready_at TIMESTAMP NULL;
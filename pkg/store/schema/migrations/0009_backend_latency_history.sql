@@ -0,0 +1,13 @@
+
+
+--
+-- Backend Latency History
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The latencies of the last node syncs are kept,
+-- so a single slow response does not affect the routing.
+ALTER TABLE backends
+  ADD latency_history jsonb NOT NULL DEFAULT '[]';
@@ -0,0 +1,14 @@
+
+
+--
+-- Frontend Meeting Peak Attendees
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The highest number of attendees seen in a meeting
+-- is kept, so the size of the next run of the meeting
+-- can be estimated when selecting a backend.
+ALTER TABLE frontend_meetings
+  ADD peak_attendees integer NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+
+
+--
+-- Meeting Empty Since
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- The time since a meeting has no attendees. Empty
+-- meetings are ended after a timeout.
+ALTER TABLE meetings
+  ADD empty_since TIMESTAMP NULL;
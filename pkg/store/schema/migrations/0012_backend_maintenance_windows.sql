@@ -0,0 +1,51 @@
+
+
+--
+-- Backend Maintenance Windows
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- In drain mode, a backend does not accept new meetings
+-- during a maintenance window. In force mode, remaining
+-- meetings are ended at the deadline.
+CREATE TYPE maintenance_mode AS ENUM (
+    'drain',
+    'force'
+);
+
+-- A maintenance window is scheduled, then active and
+-- done after the end. The controller re-enables the
+-- backend when the window is done and the backend was
+-- disabled by the window.
+CREATE TYPE maintenance_state AS ENUM (
+    'scheduled',
+    'active',
+    'done'
+);
+
+CREATE TABLE maintenance_windows (
+    id         uuid              DEFAULT uuid_generate_v4() PRIMARY KEY,
+
+    backend_id uuid              NOT NULL
+               REFERENCES        backends(id)
+               ON DELETE         CASCADE,
+
+    mode       maintenance_mode  NOT NULL DEFAULT 'drain',
+    state      maintenance_state NOT NULL DEFAULT 'scheduled',
+
+    starts_at  TIMESTAMP         NOT NULL,
+    ends_at    TIMESTAMP         NOT NULL,
+    deadline   TIMESTAMP         NULL DEFAULT NULL,
+
+    -- The backend is only enabled again at the end,
+    -- if it was disabled by the window.
+    disabled_backend BOOLEAN     NOT NULL DEFAULT false,
+
+    created_at TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX maintenance_windows_backend_id_idx
+          ON maintenance_windows(backend_id);
@@ -0,0 +1,14 @@
+
+
+--
+-- Command State Failed
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- A command is failed after all attempts were
+-- exhausted or when it expired before it was run.
+-- Adding an enum value must be the only statement
+-- of the migration.
+ALTER TYPE command_state ADD VALUE IF NOT EXISTS 'failed';
@@ -0,0 +1,57 @@
+
+
+--
+-- Command Retries
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- A failed command is retried with an exponential
+-- backoff until the maximum attempts are exhausted.
+ALTER TABLE commands
+  ADD attempts     INTEGER   NOT NULL DEFAULT 0,
+  ADD max_attempts INTEGER   NOT NULL DEFAULT 1,
+  ADD not_before   TIMESTAMP NULL     DEFAULT NULL;
+
+-- Expired commands are no longer removed silently:
+-- Commands which were never run before the deadline
+-- are failed. Only failed commands with retries and
+-- commands which can be created through the API
+-- (end_all_meetings, end_frontend_meetings) are kept
+-- for a week, so they can be inspected and requeued.
+-- Other commands are removed after the deadline as before.
+CREATE OR REPLACE FUNCTION after_commands_insert() RETURNS TRIGGER AS $$
+BEGIN
+  -- Housekeeping: Fail expired commands which are kept
+  UPDATE commands
+     SET state      = 'failed',
+         result     = '"expired"',
+         stopped_at = now() AT TIME ZONE 'utc'
+   WHERE state = 'requested'
+     AND attempts = 0
+     AND (max_attempts > 1
+          OR action IN ('end_all_meetings', 'end_frontend_meetings'))
+     AND (deadline + interval '1 minute')
+         < now() AT TIME ZONE 'utc';
+
+  -- Remove expired commands which are not kept,
+  -- finished commands and old failed commands.
+  DELETE FROM commands
+   WHERE (max_attempts <= 1
+          AND action NOT IN ('end_all_meetings', 'end_frontend_meetings')
+          AND (deadline + interval '1 minute')
+              < now() AT TIME ZONE 'utc')
+      OR (state IN ('success', 'error')
+          AND (deadline + interval '1 minute')
+              < now() AT TIME ZONE 'utc')
+      OR (state = 'failed'
+          AND created_at + interval '7 days'
+              < now() AT TIME ZONE 'utc');
+
+  -- Finally inform instances, that a new command
+  -- was queued.
+  NOTIFY commands_queue;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
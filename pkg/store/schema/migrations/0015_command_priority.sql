@@ -0,0 +1,17 @@
+
+
+--
+-- Command Priority
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- Commands with a higher priority are processed
+-- first, then in the order they were queued.
+ALTER TABLE commands
+  ADD priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX commands_requested_priority_idx
+          ON commands(priority DESC, seq ASC)
+       WHERE state = 'requested';
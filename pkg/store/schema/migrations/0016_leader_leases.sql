@@ -0,0 +1,20 @@
+
+
+--
+-- Leader Leases
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- A lease is held by a single instance until it
+-- expires. The holder renews the lease periodically.
+-- When the holder dies, another instance takes over
+-- after the lease expired.
+CREATE TABLE leader_leases (
+    name        VARCHAR(80)  NOT NULL PRIMARY KEY,
+    holder      VARCHAR(255) NOT NULL,
+
+    acquired_at TIMESTAMP    NOT NULL,
+    expires_at  TIMESTAMP    NOT NULL
+);
@@ -0,0 +1,27 @@
+
+
+--
+-- Instances
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- Each b3scaled instance periodically reports
+-- its state. Stale instances are removed by the
+-- garbage collection.
+CREATE TABLE instances (
+    id                 VARCHAR(255) NOT NULL PRIMARY KEY,
+
+    hostname           VARCHAR(255) NOT NULL,
+    version            VARCHAR(80)  NOT NULL,
+    build              VARCHAR(80)  NOT NULL,
+
+    started_at         TIMESTAMP    NOT NULL,
+    heartbeat_at       TIMESTAMP    NOT NULL,
+
+    commands_processed BIGINT       NOT NULL DEFAULT 0,
+
+    last_error         TEXT         NULL,
+    last_error_at      TIMESTAMP    NULL
+);
@@ -0,0 +1,21 @@
+--
+-- Frontend Reservations
+--
+-- %% Author: annika
+-- %% Date: 2026-10-17
+--
+
+-- Reservations are created when a new meeting of a
+-- frontend with a meetings limit passed the quota check.
+-- They are removed when the create request is done.
+CREATE TABLE frontend_reservations (
+    frontend_id uuid         NOT NULL
+                REFERENCES   frontends(id)
+                ON DELETE    CASCADE,
+
+    meeting_id  VARCHAR(255) NOT NULL,
+
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (frontend_id, meeting_id)
+);
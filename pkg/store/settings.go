@@ -1,6 +1,8 @@
 package store
 
-import "github.com/b3scale/b3scale/pkg/bbb"
+import (
+	"github.com/b3scale/b3scale/pkg/bbb"
+)
 
 // Tags are a list of strings with labels to declare
 // for example backend capabilities
@@ -9,6 +11,17 @@ type Tags []string
 // BackendSettings hold per backend runtime configuration.
 type BackendSettings struct {
 	Tags Tags `json:"tags,omitempty" doc:"The backend provides these tags. A frontend can require a list of tags. This can be used to dedicate parts of the cluster."`
+
+	MaxMeetings     int `json:"max_meetings,omitempty" doc:"Do not create new meetings on the backend, when this number of meetings is reached. 0 means unlimited." example:"50"`
+	MaxAttendees    int `json:"max_attendees,omitempty" doc:"Do not create new meetings on the backend, when this number of attendees is reached. 0 means unlimited." example:"500"`
+	MaxVideoStreams int `json:"max_video_streams,omitempty" doc:"Do not create new meetings on the backend, when this number of video streams is reached. 0 means unlimited." example:"200"`
+}
+
+// A TagPreference is a weighted tag. Backends providing
+// preferred tags are ranked higher.
+type TagPreference struct {
+	Tag    string  `json:"tag" doc:"The preferred tag." example:"customer42"`
+	Weight float64 `json:"weight,omitempty" doc:"The weight of the tag when ranking backends. Defaults to 1." example:"2.0"`
 }
 
 // DefaultPresentationSettings configure a per frontend
@@ -22,8 +35,19 @@ type DefaultPresentationSettings struct {
 // frontend.
 type FrontendSettings struct {
 	RequiredTags        Tags                         `json:"required_tags,omitempty" doc:"When selecting a backend for creating a meeting, only consider nodes providing all of the required tags."`
+	AllowedRequestTags  Tags                         `json:"allowed_request_tags,omitempty" doc:"Tags which may be required for a single meeting through a parameter of the create request. Other tags are ignored."`
+	RequestTagsParam    string                       `json:"request_tags_param,omitempty" doc:"The create parameter with a comma separated list of required tags for the meeting. Defaults to meta_b3scale-tags." example:"meta_b3scale-tags"`
+	SizeHintParam       string                       `json:"size_hint_param,omitempty" doc:"The create parameter with the expected number of attendees of the meeting. Defaults to meta_b3scale-expected-attendees." example:"meta_b3scale-expected-attendees"`
+	PreferredTags       []*TagPreference             `json:"preferred_tags,omitempty" doc:"When selecting a backend for creating a meeting, prefer nodes providing these tags. Other nodes are used if no preferred node is available."`
+	RoutingRules        []*RoutingRule               `json:"routing_rules,omitempty" doc:"Rules requiring tags for a meeting, depending on the parameters of the create request."`
 	DefaultPresentation *DefaultPresentationSettings `json:"default_presentation,omitempty"`
 
 	CreateDefaultParams  bbb.Params `json:"create_default_params,omitempty" doc:"Provide key value params, which will be used as a default when a meeting is created. See the BBB api documentation for which params are valid. The param value must be encoded as string."`
 	CreateOverrideParams bbb.Params `json:"create_override_params,omitempty" doc:"A key value set of params which will override parameters from the frontend when a meeting is created."`
+
+	MaxConcurrentMeetings int `json:"max_concurrent_meetings,omitempty" doc:"Do not create new meetings, when this number of meetings of the frontend is running. 0 means unlimited." example:"20"`
+	MaxAttendees          int `json:"max_attendees,omitempty" doc:"Do not allow joining meetings, when this number of attendees in all meetings of the frontend is reached. 0 means unlimited." example:"500"`
+	MaxMeetingDuration    int `json:"max_meeting_duration,omitempty" doc:"The maximum duration of a meeting in minutes. The duration parameter of the create request is limited to this value. 0 means unlimited." example:"240"`
+
+	StressStrategy string `json:"stress_strategy,omitempty" doc:"Select the strategy for scoring backends when a meeting is created. If none is given, the cluster default is used." example:"attendees" enum:"default,attendees,latency,meetings,packing"`
 }